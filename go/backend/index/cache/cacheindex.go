@@ -1,19 +1,32 @@
 package cache
 
 import (
+	"unsafe"
+
 	"github.com/Fantom-foundation/Carmen/go/backend/index"
 	"github.com/Fantom-foundation/Carmen/go/common"
 )
 
-// Index wraps another index and a cache
+// Index wraps another index and a cache. In addition to the positive
+// cache of resolved key->index mappings, it keeps a smaller negative
+// cache of keys recently confirmed absent from wrapped, so that a
+// workload repeatedly probing non-existent keys (e.g. Contains checks
+// before insertion) does not pay the full cost of the wrapped index on
+// every miss.
 type Index[K comparable, I common.Identifier] struct {
 	wrapped index.Index[K, I]
 	cache   *common.Cache[K, I]
+	missing *common.Cache[K, struct{}]
 }
 
 // NewIndex constructs a new Index instance, which either delegates to the wrapped index or gets data from the cache if it has them.
-func NewIndex[K comparable, I common.Identifier](wrapped index.Index[K, I], cacheCapacity int) *Index[K, I] {
-	return &Index[K, I]{wrapped, common.NewCache[K, I](cacheCapacity)}
+// missingCacheCapacity bounds the size of the negative (known-missing) cache; pass 0 to disable negative caching.
+func NewIndex[K comparable, I common.Identifier](wrapped index.Index[K, I], cacheCapacity int, missingCacheCapacity int) *Index[K, I] {
+	var missing *common.Cache[K, struct{}]
+	if missingCacheCapacity > 0 {
+		missing = common.NewCache[K, struct{}](missingCacheCapacity)
+	}
+	return &Index[K, I]{wrapped, common.NewCache[K, I](cacheCapacity), missing}
 }
 
 // GetOrAdd returns an index mapping for the key, or creates the new index
@@ -22,6 +35,7 @@ func (m *Index[K, I]) GetOrAdd(key K) (idx I, err error) {
 	if !exists {
 		idx, err = m.wrapped.GetOrAdd(key)
 		m.cache.Set(key, idx)
+		m.forgetMissing(key)
 	}
 	return
 }
@@ -30,8 +44,15 @@ func (m *Index[K, I]) GetOrAdd(key K) (idx I, err error) {
 func (m *Index[K, I]) Get(key K) (idx I, err error) {
 	idx, exists := m.cache.Get(key)
 	if !exists {
+		if m.isKnownMissing(key) {
+			return idx, index.ErrNotFound
+		}
 		idx, err = m.wrapped.Get(key)
-		m.cache.Set(key, idx)
+		if err == index.ErrNotFound {
+			m.rememberMissing(key)
+		} else {
+			m.cache.Set(key, idx)
+		}
 	}
 	return
 }
@@ -39,15 +60,54 @@ func (m *Index[K, I]) Get(key K) (idx I, err error) {
 // Contains returns whether the key exists in the mapping or not.
 func (m *Index[K, I]) Contains(key K) (exists bool) {
 	_, exists = m.cache.Get(key)
-	if !exists {
+	if !exists && !m.isKnownMissing(key) {
 		if idx, err := m.wrapped.Get(key); err != index.ErrNotFound {
+			exists = true
 			m.cache.Set(key, idx)
+		} else {
+			m.rememberMissing(key)
 		}
 	}
 
 	return
 }
 
+func (m *Index[K, I]) isKnownMissing(key K) bool {
+	if m.missing == nil {
+		return false
+	}
+	_, missing := m.missing.Get(key)
+	return missing
+}
+
+func (m *Index[K, I]) rememberMissing(key K) {
+	if m.missing != nil {
+		m.missing.Set(key, struct{}{})
+	}
+}
+
+func (m *Index[K, I]) forgetMissing(key K) {
+	if m.missing != nil {
+		m.missing.Delete(key)
+	}
+}
+
+// GetMemoryFootprint provides sizes of individual components of the
+// index in memory, consistent with how common.CachedHasher reports its
+// cache/hashersPool children.
+func (m *Index[K, I]) GetMemoryFootprint() *common.MemoryFootprint {
+	mf := common.NewMemoryFootprint(unsafe.Sizeof(*m))
+	var key K
+	var idx I
+	mf.AddChild("cache", common.NewMemoryFootprint(uintptr(m.cache.Len())*(unsafe.Sizeof(key)+unsafe.Sizeof(idx))))
+	missingSize := 0
+	if m.missing != nil {
+		missingSize = m.missing.Len()
+	}
+	mf.AddChild("missingCache", common.NewMemoryFootprint(uintptr(missingSize)*unsafe.Sizeof(key)))
+	return mf
+}
+
 // GetStateHash returns the index hash.
 func (m *Index[K, I]) GetStateHash() (common.Hash, error) {
 	return m.wrapped.GetStateHash()
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/backend/index"
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// countingIndex is a minimal index.Index fake that counts how many times
+// Get is called per key, so tests can assert that a repeated miss on the
+// same key is served from the negative cache rather than re-querying the
+// wrapped index.
+type countingIndex struct {
+	values map[string]uint32
+	gets   map[string]int
+	next   uint32
+}
+
+func newCountingIndex() *countingIndex {
+	return &countingIndex{values: map[string]uint32{}, gets: map[string]int{}}
+}
+
+func (c *countingIndex) GetOrAdd(key string) (uint32, error) {
+	if idx, exists := c.values[key]; exists {
+		return idx, nil
+	}
+	c.next++
+	c.values[key] = c.next
+	return c.next, nil
+}
+
+func (c *countingIndex) Get(key string) (uint32, error) {
+	c.gets[key]++
+	if idx, exists := c.values[key]; exists {
+		return idx, nil
+	}
+	return 0, index.ErrNotFound
+}
+
+func (c *countingIndex) GetStateHash() (common.Hash, error) {
+	return common.Hash{}, nil
+}
+
+func (c *countingIndex) Close() error {
+	return nil
+}
+
+func TestCacheIndex_NegativeCacheAvoidsRepeatedMisses(t *testing.T) {
+	wrapped := newCountingIndex()
+	idx := NewIndex[string, uint32](wrapped, 10, 10)
+
+	for i := 0; i < 3; i++ {
+		if _, err := idx.Get("missing"); !errors.Is(err, index.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+
+	if got := wrapped.gets["missing"]; got != 1 {
+		t.Errorf("expected the wrapped index to be queried once, got %d queries", got)
+	}
+}
+
+func TestCacheIndex_ContainsAlsoUsesNegativeCache(t *testing.T) {
+	wrapped := newCountingIndex()
+	idx := NewIndex[string, uint32](wrapped, 10, 10)
+
+	for i := 0; i < 3; i++ {
+		if idx.Contains("missing") {
+			t.Fatalf("expected key to be reported as absent")
+		}
+	}
+
+	if got := wrapped.gets["missing"]; got != 1 {
+		t.Errorf("expected the wrapped index to be queried once, got %d queries", got)
+	}
+}
+
+func TestCacheIndex_GetOrAddForgetsNegativeEntry(t *testing.T) {
+	wrapped := newCountingIndex()
+	idx := NewIndex[string, uint32](wrapped, 10, 10)
+
+	if _, err := idx.Get("key"); !errors.Is(err, index.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if _, err := idx.GetOrAdd("key"); err != nil {
+		t.Fatalf("failed to add key: %v", err)
+	}
+
+	if idx.isKnownMissing("key") {
+		t.Errorf("expected GetOrAdd to remove the key from the negative cache")
+	}
+
+	if _, err := idx.Get("key"); err != nil {
+		t.Errorf("expected key to be resolvable after GetOrAdd, got %v", err)
+	}
+}
+
+func TestCacheIndex_ZeroMissingCapacityDisablesNegativeCaching(t *testing.T) {
+	wrapped := newCountingIndex()
+	idx := NewIndex[string, uint32](wrapped, 10, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, err := idx.Get("missing"); !errors.Is(err, index.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	}
+
+	if got := wrapped.gets["missing"]; got != 3 {
+		t.Errorf("expected every miss to reach the wrapped index when negative caching is disabled, got %d queries", got)
+	}
+}
@@ -0,0 +1,36 @@
+package pagepool
+
+// Page is the minimal contract a PagePool entry must implement: content
+// that can be exported to, and restored from, a byte slice.
+type Page interface {
+	Size() int
+	ToBytes() []byte
+	FromBytes(data []byte)
+}
+
+// RawPage is the simplest Page implementation: an opaque byte slice
+// bounded by a fixed capacity.
+type RawPage struct {
+	capacity int
+	data     []byte
+}
+
+// NewRawPage creates an empty page with the given byte capacity.
+func NewRawPage(capacity int) *RawPage {
+	return &RawPage{capacity: capacity}
+}
+
+func (p *RawPage) Size() int {
+	return len(p.data)
+}
+
+func (p *RawPage) ToBytes() []byte {
+	return p.data
+}
+
+func (p *RawPage) FromBytes(data []byte) {
+	if len(data) > p.capacity {
+		data = data[:p.capacity]
+	}
+	p.data = append([]byte(nil), data...)
+}
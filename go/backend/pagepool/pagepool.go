@@ -0,0 +1,190 @@
+package pagepool
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// JournalConfig configures the optional write-ahead journal guarding
+// dirty-page evictions. A zero-value JournalConfig (empty Directory)
+// disables journaling, matching a PagePool's behavior before this
+// feature was introduced.
+type JournalConfig struct {
+	Directory   string
+	SegmentSize int64
+	SyncMode    SyncMode
+}
+
+// PagePool caches up to poolSize pages of type P, keyed by K, in front of
+// a PageStore. Pages evicted from the in-memory cache are written
+// through to the store; if a JournalConfig is supplied, the write-through
+// is guarded by a write-ahead journal so a crash between appending the
+// journal record and writing the store cannot leave the store holding a
+// half-applied mutation - replaying the journal on the next open
+// finishes it.
+type PagePool[K comparable, P Page] struct {
+	pageStore   PageStore[K]
+	pages       *common.Cache[K, P]
+	pageFactory func() P
+	journal     *pageWAL[K]
+
+	// inCheckpoint suspends the segment-size check while a Checkpoint is
+	// already flushing and rotating the journal, so the flush it triggers
+	// doesn't recursively trigger another one.
+	inCheckpoint bool
+}
+
+// NewPagePool creates a page pool of the given size, backed by
+// pageStore, creating new pages via pageFactory on a miss. An optional
+// JournalConfig enables the crash-safe write-ahead journal described on
+// PagePool; passing none leaves journaling disabled.
+func NewPagePool[K comparable, P Page](poolSize int, pageStore PageStore[K], pageFactory func() P, journalConfig ...JournalConfig) *PagePool[K, P] {
+	pool := &PagePool[K, P]{
+		pageStore:   pageStore,
+		pages:       common.NewCache[K, P](poolSize),
+		pageFactory: pageFactory,
+	}
+
+	if len(journalConfig) > 0 && journalConfig[0].Directory != "" {
+		if wal, err := newPageWAL[K](journalConfig[0]); err == nil {
+			pool.journal = wal
+			_ = wal.replay(
+				func(id K, data []byte) error {
+					page := pageFactory()
+					page.FromBytes(data)
+					return pageStore.Store(id, page)
+				},
+				func(id K) error {
+					_, err := pageStore.Remove(id)
+					return err
+				},
+			)
+		}
+	}
+
+	return pool
+}
+
+// Get returns the page stored under id, creating it via pageFactory and
+// loading any persisted content from the store on a cache miss.
+func (p *PagePool[K, P]) Get(id K) (P, error) {
+	if page, exists := p.pages.Get(id); exists {
+		return page, nil
+	}
+
+	page := p.pageFactory()
+	if err := p.pageStore.Load(id, page); err != nil {
+		var zero P
+		return zero, err
+	}
+	if err := p.put(id, page); err != nil {
+		var zero P
+		return zero, err
+	}
+	return page, nil
+}
+
+// put inserts page under id, write-through persisting the
+// least-recently-used page if the pool is at capacity.
+func (p *PagePool[K, P]) put(id K, page P) error {
+	evictedId, evictedPage, evicted := p.pages.Set(id, page)
+	if !evicted {
+		return nil
+	}
+	return p.evict(evictedId, evictedPage)
+}
+
+// evict journals and then persists page under id to the underlying
+// store, the sequencing that makes a mid-crash recoverable by replay. A
+// page that was never populated (Size zero) carries nothing worth
+// persisting and is simply dropped from the cache, so a pool slot never
+// churns out a spurious store entry merely for having been touched by Get.
+func (p *PagePool[K, P]) evict(id K, page P) error {
+	if page.Size() == 0 {
+		return nil
+	}
+	if p.journal != nil {
+		if err := p.journal.append(walOpPut, id, page.ToBytes()); err != nil {
+			return fmt.Errorf("failed to journal eviction of page %v: %w", id, err)
+		}
+	}
+	if err := p.pageStore.Store(id, page); err != nil {
+		return err
+	}
+	return p.rotateJournalIfFull()
+}
+
+// Remove deletes id from the pool and the underlying store, reporting
+// whether it existed in the store.
+func (p *PagePool[K, P]) Remove(id K) (bool, error) {
+	p.pages.Delete(id)
+
+	if p.journal != nil {
+		if err := p.journal.append(walOpRemove, id, nil); err != nil {
+			return false, fmt.Errorf("failed to journal removal of page %v: %w", id, err)
+		}
+	}
+	existed, err := p.pageStore.Remove(id)
+	if err != nil {
+		return existed, err
+	}
+	if err := p.rotateJournalIfFull(); err != nil {
+		return existed, err
+	}
+	return existed, nil
+}
+
+// rotateJournalIfFull checkpoints the pool once its journal has grown past
+// the configured JournalConfig.SegmentSize, bounding how large the journal
+// file is allowed to grow between explicit Checkpoint calls. It is a no-op
+// while a Checkpoint is already in progress, to avoid recursing back into
+// itself through Checkpoint's own flushAll.
+func (p *PagePool[K, P]) rotateJournalIfFull() error {
+	if p.journal == nil || p.inCheckpoint || !p.journal.exceedsSegmentSize() {
+		return nil
+	}
+	return p.Checkpoint()
+}
+
+// Checkpoint flushes every cached page to the store and rotates the
+// journal, so a crash immediately afterward has nothing left to replay.
+func (p *PagePool[K, P]) Checkpoint() error {
+	p.inCheckpoint = true
+	defer func() { p.inCheckpoint = false }()
+
+	if err := p.flushAll(); err != nil {
+		return err
+	}
+	if p.journal != nil {
+		return p.journal.rotate()
+	}
+	return nil
+}
+
+func (p *PagePool[K, P]) flushAll() error {
+	for _, id := range p.pages.Keys() {
+		page, exists := p.pages.Get(id)
+		if !exists {
+			continue
+		}
+		if err := p.evict(id, page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close flushes all cached pages to the store and closes the store and,
+// if enabled, the journal.
+func (p *PagePool[K, P]) Close() error {
+	if err := p.flushAll(); err != nil {
+		return err
+	}
+	if p.journal != nil {
+		if err := p.journal.Close(); err != nil {
+			return err
+		}
+	}
+	return p.pageStore.Close()
+}
@@ -0,0 +1,192 @@
+package pagepool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SyncMode controls how aggressively a pageWAL fsyncs its log file.
+type SyncMode int
+
+const (
+	// SyncAlways fsyncs after every appended record - the safest and
+	// default mode, at the cost of one fsync per eviction/removal.
+	SyncAlways SyncMode = iota
+	// SyncInterval only fsyncs when the journal is rotated (Checkpoint,
+	// Close), trading durability of the most recent records for write
+	// throughput.
+	SyncInterval
+	// SyncNone never explicitly fsyncs, relying entirely on the OS to
+	// flush pages on its own schedule.
+	SyncNone
+)
+
+const defaultSegmentSize = 64 * 1024 * 1024
+
+const (
+	walOpPut byte = iota
+	walOpRemove
+)
+
+const walFileName = "pagepool.wal"
+
+// walRecord is the payload gob-encoded into a single journal entry.
+// Using gob rather than a hand-rolled binary layout lets the record
+// carry an arbitrary comparable page-id type K without PagePool having
+// to supply a K<->bytes codec.
+type walRecord[K any] struct {
+	Op   byte
+	ID   K
+	Data []byte
+}
+
+// pageWAL is the write-ahead journal guarding PagePool's dirty-page
+// evictions: a record is appended - and, depending on SyncMode, fsynced
+// - before the corresponding store mutation is applied, so a crash
+// between the two always leaves a journal entry replay can use to finish
+// the mutation the store itself missed. Each record is framed with a
+// length and a CRC32 checksum so a partially-written trailing record
+// (the signature of a crash mid-append) is detected and discarded rather
+// than corrupting replay.
+type pageWAL[K any] struct {
+	file        *os.File
+	segmentSize int64
+	syncMode    SyncMode
+	written     int64
+}
+
+func newPageWAL[K any](config JournalConfig) (*pageWAL[K], error) {
+	if err := os.MkdirAll(config.Directory, 0700); err != nil {
+		return nil, err
+	}
+	segmentSize := config.SegmentSize
+	if segmentSize <= 0 {
+		segmentSize = defaultSegmentSize
+	}
+	path := filepath.Join(config.Directory, walFileName)
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return &pageWAL[K]{file: file, segmentSize: segmentSize, syncMode: config.SyncMode, written: info.Size()}, nil
+}
+
+// replay reads every record currently in the journal, in order, applying
+// puts and removes via the supplied callbacks. It is called once, on
+// construction, before the journal resumes appending.
+func (w *pageWAL[K]) replay(applyPut func(id K, data []byte) error, applyRemove func(id K) error) error {
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	defer w.file.Seek(0, io.SeekEnd)
+
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(w.file, header[:]); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			// A truncated header is the tail of a crash mid-append;
+			// everything before it was already durably written.
+			return nil
+		}
+		length := binary.BigEndian.Uint32(header[0:4])
+		wantChecksum := binary.BigEndian.Uint32(header[4:8])
+
+		payload := make([]byte, length)
+		if _, err := io.ReadFull(w.file, payload); err != nil {
+			return nil
+		}
+		if crc32.ChecksumIEEE(payload) != wantChecksum {
+			return nil
+		}
+
+		var record walRecord[K]
+		if err := gob.NewDecoder(bytes.NewReader(payload)).Decode(&record); err != nil {
+			return fmt.Errorf("failed to decode journal record: %w", err)
+		}
+
+		switch record.Op {
+		case walOpPut:
+			if err := applyPut(record.ID, record.Data); err != nil {
+				return err
+			}
+		case walOpRemove:
+			if err := applyRemove(record.ID); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// append writes a single record, fsyncing immediately if syncMode is
+// SyncAlways.
+func (w *pageWAL[K]) append(op byte, id K, data []byte) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(walRecord[K]{Op: op, ID: id, Data: data}); err != nil {
+		return err
+	}
+	payload := buf.Bytes()
+	checksum := crc32.ChecksumIEEE(payload)
+
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[0:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(header[4:8], checksum)
+
+	if _, err := w.file.Write(header[:]); err != nil {
+		return err
+	}
+	if _, err := w.file.Write(payload); err != nil {
+		return err
+	}
+	w.written += int64(len(header) + len(payload))
+
+	if w.syncMode == SyncAlways {
+		return w.file.Sync()
+	}
+	return nil
+}
+
+// exceedsSegmentSize reports whether the journal has grown past its
+// configured segment size and should be rotated via a Checkpoint before
+// more records are appended to it.
+func (w *pageWAL[K]) exceedsSegmentSize() bool {
+	return w.written >= w.segmentSize
+}
+
+// rotate truncates the journal back to empty, for use once every pending
+// record has been durably applied to the underlying store.
+func (w *pageWAL[K]) rotate() error {
+	if err := w.file.Truncate(0); err != nil {
+		return err
+	}
+	if _, err := w.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	w.written = 0
+	if w.syncMode == SyncNone {
+		return nil
+	}
+	return w.file.Sync()
+}
+
+func (w *pageWAL[K]) Close() error {
+	if w.syncMode == SyncInterval {
+		if err := w.file.Sync(); err != nil {
+			w.file.Close()
+			return err
+		}
+	}
+	return w.file.Close()
+}
@@ -0,0 +1,150 @@
+package pagepool
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// TestPagePoolJournal_RecoversPendingEvictionAfterSimulatedCrash appends a
+// journal record for a page eviction without ever applying the matching
+// store write - simulating a crash between the two - then opens a fresh
+// PagePool against the same journal directory and store, and verifies
+// replay reproduces the page a clean Close would have persisted.
+func TestPagePoolJournal_RecoversPendingEvictionAfterSimulatedCrash(t *testing.T) {
+	dir := t.TempDir()
+	store := NewMemoryPageStore[int](nextIdGenerator())
+	pageFactory := func() *RawPage { return NewRawPage(common.PageSize) }
+	config := JournalConfig{Directory: filepath.Join(dir, "journal")}
+
+	wal, err := newPageWAL[int](config)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	pending := NewRawPage(common.PageSize)
+	pending.FromBytes(data)
+	if err := wal.append(walOpPut, pageA, pending.ToBytes()); err != nil {
+		t.Fatalf("failed to append journal record: %v", err)
+	}
+	// Simulate the crash: the journal record exists, but the store write
+	// it was guarding never happened.
+	if err := wal.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+	probe := NewRawPage(common.PageSize)
+	if err := store.Load(pageA, probe); err != nil || probe.Size() != 0 {
+		t.Fatalf("store should not yet hold the page before recovery")
+	}
+
+	// Reopen a pool against the same journal directory and store; replay
+	// must finish the interrupted eviction.
+	recovered := NewPagePool[int, *RawPage](3, store, pageFactory, config)
+	defer recovered.Close()
+
+	loaded := NewRawPage(common.PageSize)
+	if err := store.Load(pageA, loaded); err != nil || loaded.Size() == 0 {
+		t.Errorf("replay did not recover the pending eviction: %v", loaded)
+	}
+	if string(loaded.ToBytes()) != string(data) {
+		t.Errorf("recovered page content = %v, want %v", loaded.ToBytes(), data)
+	}
+}
+
+// TestPagePoolJournal_RecoversPendingRemoval mirrors the crash scenario
+// above for a removal: the journal records the removal but the store
+// delete never happens, and replay on reopen must finish it.
+func TestPagePoolJournal_RecoversPendingRemoval(t *testing.T) {
+	dir := t.TempDir()
+	store := NewMemoryPageStore[int](nextIdGenerator())
+	present := NewRawPage(common.PageSize)
+	present.FromBytes(data)
+	if err := store.Store(pageA, present); err != nil {
+		t.Fatalf("failed to seed store: %v", err)
+	}
+
+	config := JournalConfig{Directory: filepath.Join(dir, "journal")}
+	wal, err := newPageWAL[int](config)
+	if err != nil {
+		t.Fatalf("failed to open journal: %v", err)
+	}
+	if err := wal.append(walOpRemove, pageA, nil); err != nil {
+		t.Fatalf("failed to append journal record: %v", err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatalf("failed to close journal: %v", err)
+	}
+
+	pageFactory := func() *RawPage { return NewRawPage(common.PageSize) }
+	recovered := NewPagePool[int, *RawPage](3, store, pageFactory, config)
+	defer recovered.Close()
+
+	probe := NewRawPage(common.PageSize)
+	if err := store.Load(pageA, probe); err != nil || probe.Size() != 0 {
+		t.Errorf("replay did not recover the pending removal: %v", probe)
+	}
+}
+
+// TestPagePoolJournal_CheckpointRotatesJournal verifies a Checkpoint
+// leaves nothing for replay to redo on the next open.
+func TestPagePoolJournal_CheckpointRotatesJournal(t *testing.T) {
+	dir := t.TempDir()
+	store := NewMemoryPageStore[int](nextIdGenerator())
+	pageFactory := func() *RawPage { return NewRawPage(common.PageSize) }
+	config := JournalConfig{Directory: filepath.Join(dir, "journal")}
+
+	pool := NewPagePool[int, *RawPage](3, store, pageFactory, config)
+	page, err := pool.Get(pageA)
+	if err != nil {
+		t.Fatalf("failed to get page: %v", err)
+	}
+	page.FromBytes(data)
+
+	if err := pool.Checkpoint(); err != nil {
+		t.Fatalf("checkpoint failed: %v", err)
+	}
+	if pool.journal.written != 0 {
+		t.Errorf("expected Checkpoint to rotate the journal, got %d bytes still pending", pool.journal.written)
+	}
+	if err := pool.Close(); err != nil {
+		t.Fatalf("close failed: %v", err)
+	}
+
+	reopened := NewPagePool[int, *RawPage](3, store, pageFactory, config)
+	defer reopened.Close()
+
+	loaded := NewRawPage(common.PageSize)
+	if err := store.Load(pageA, loaded); err != nil || loaded.Size() == 0 {
+		t.Errorf("checkpointed page missing after reopen: %v", loaded)
+	}
+}
+
+// TestPagePoolJournal_SegmentSizeTriggersAutomaticCheckpoint verifies that
+// once the journal grows past JournalConfig.SegmentSize, the pool rotates
+// it on its own instead of letting it grow unboundedly until the caller
+// happens to call Checkpoint.
+func TestPagePoolJournal_SegmentSizeTriggersAutomaticCheckpoint(t *testing.T) {
+	dir := t.TempDir()
+	store := NewMemoryPageStore[int](nextIdGenerator())
+	page := NewRawPage(common.PageSize)
+	page.FromBytes(data)
+
+	// A segment size smaller than a single evicted record forces a
+	// rotation on the very first eviction that goes through the journal.
+	config := JournalConfig{Directory: filepath.Join(dir, "journal"), SegmentSize: 1}
+	pool := NewPagePool[int, *RawPage](1, store, func() *RawPage { return NewRawPage(common.PageSize) }, config)
+	defer pool.Close()
+
+	// poolSize 1: putting a second page forces pageA to be evicted and
+	// journaled before the journal is ever rotated explicitly.
+	if err := pool.put(pageA, page); err != nil {
+		t.Fatalf("failed to put page: %v", err)
+	}
+	if err := pool.put(pageB, page); err != nil {
+		t.Fatalf("failed to put page: %v", err)
+	}
+
+	if pool.journal.written != 0 {
+		t.Errorf("expected the oversized journal to have been rotated automatically, got %d bytes still pending", pool.journal.written)
+	}
+}
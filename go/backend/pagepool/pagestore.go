@@ -0,0 +1,56 @@
+package pagepool
+
+// PageStore is the durable backing store a PagePool evicts pages into and
+// loads them back from, keyed by a caller-chosen page identifier.
+type PageStore[K comparable] interface {
+	// Load reads the page stored under id into page, if one exists; page
+	// is left unmodified if no page is stored under id.
+	Load(id K, page Page) error
+	// Store persists page's content under id, overwriting any existing entry.
+	Store(id K, page Page) error
+	// Remove deletes any page stored under id, reporting whether one existed.
+	Remove(id K) (bool, error)
+	Close() error
+}
+
+// MemoryPageStore is an in-memory PageStore, mainly useful for tests and
+// for pools that do not need cross-process durability.
+type MemoryPageStore[K comparable] struct {
+	pages  map[K][]byte
+	nextId func() K
+}
+
+// NewMemoryPageStore creates an empty in-memory PageStore. nextId is
+// reserved for pools that mint their own page identifiers rather than
+// have callers choose them; see GenerateNextId.
+func NewMemoryPageStore[K comparable](nextId func() K) *MemoryPageStore[K] {
+	return &MemoryPageStore[K]{pages: map[K][]byte{}, nextId: nextId}
+}
+
+func (s *MemoryPageStore[K]) Load(id K, page Page) error {
+	if data, exists := s.pages[id]; exists {
+		page.FromBytes(data)
+	}
+	return nil
+}
+
+func (s *MemoryPageStore[K]) Store(id K, page Page) error {
+	s.pages[id] = append([]byte(nil), page.ToBytes()...)
+	return nil
+}
+
+func (s *MemoryPageStore[K]) Remove(id K) (bool, error) {
+	_, exists := s.pages[id]
+	delete(s.pages, id)
+	return exists, nil
+}
+
+func (s *MemoryPageStore[K]) Close() error {
+	return nil
+}
+
+// GenerateNextId produces the next page identifier using the generator
+// supplied at construction.
+func (s *MemoryPageStore[K]) GenerateNextId() K {
+	return s.nextId()
+}
@@ -0,0 +1,170 @@
+package common
+
+import (
+	"crypto/sha256"
+	"hash"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// Serializer converts a value of type T into the canonical byte sequence
+// that gets hashed by a CachedHasher.
+type Serializer[T any] interface {
+	ToBytes(value T) []byte
+}
+
+// AddressSerializer serializes an Address to its raw bytes.
+type AddressSerializer struct{}
+
+func (AddressSerializer) ToBytes(address Address) []byte {
+	return address[:]
+}
+
+// NewKeccak256Hasher, NewSha256Hasher, and NewBlake2bHasher are the
+// built-in hash.Hash factories CachedHasher can be configured with. They
+// are plain `func() hash.Hash` values so CachedHasher stays agnostic to
+// which primitive it is caching the output of.
+func NewKeccak256Hasher() hash.Hash {
+	return sha3.NewLegacyKeccak256()
+}
+
+func NewSha256Hasher() hash.Hash {
+	return sha256.New()
+}
+
+func NewBlake2bHasher() hash.Hash {
+	// blake2b.New256 only returns an error for a MAC key longer than 64
+	// bytes; a nil key never triggers that.
+	h, err := blake2b.New256(nil)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// estimatedHasherStateBytes is a rough per-instance size used to report a
+// hasherPool's memory footprint without walking into hash.Hash's private
+// state.
+const estimatedHasherStateBytes = 200
+
+// hasherPool hands out reusable hash.Hash instances created by newHash,
+// so CachedHasher.Hash does not allocate a fresh hasher on every call
+// under concurrent use.
+type hasherPool struct {
+	newHash func() hash.Hash
+
+	mu   sync.Mutex
+	pool []hash.Hash
+}
+
+func newHasherPool() *hasherPool {
+	return newHasherPoolWithFunc(NewKeccak256Hasher)
+}
+
+func newHasherPoolWithFunc(newHash func() hash.Hash) *hasherPool {
+	return &hasherPool{newHash: newHash}
+}
+
+func (p *hasherPool) getHasher() hash.Hash {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if n := len(p.pool); n > 0 {
+		h := p.pool[n-1]
+		p.pool = p.pool[:n-1]
+		return h
+	}
+	return p.newHash()
+}
+
+func (p *hasherPool) returnHasher(h hash.Hash) {
+	h.Reset()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pool = append(p.pool, h)
+}
+
+func (p *hasherPool) GetMemoryFootprint() uintptr {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return uintptr(len(p.pool)+1) * estimatedHasherStateBytes
+}
+
+// CachedHasher memoizes Hash(value) for up to capacity distinct values of
+// T, computed with a pluggable hash.Hash primitive rather than a
+// hard-coded algorithm, so callers can trade off collision resistance
+// against hashing cost (e.g. a faster non-cryptographic-strength hash for
+// benchmarking the MPT independent of Keccak cost).
+type CachedHasher[T comparable] struct {
+	serializer Serializer[T]
+	cacheMu    sync.Mutex // guards cache, which is not safe for concurrent use
+	cache      *Cache[T, Hash]
+	pool       *hasherPool
+}
+
+// NewCachedHasher creates a CachedHasher hashing with Keccak256, matching
+// the hash Carmen's MPT state uses by default.
+func NewCachedHasher[T comparable](capacity int, serializer Serializer[T]) *CachedHasher[T] {
+	return NewCachedHasherWithFunc(capacity, serializer, NewKeccak256Hasher)
+}
+
+// NewCachedHasherWithFunc creates a CachedHasher hashing with newHash,
+// allowing callers to select a different primitive than Keccak256 (e.g.
+// SHA-256 or Blake2b, for non-Ethereum deployments or for isolating the
+// MPT's algorithmic cost from its hashing cost during benchmarking).
+func NewCachedHasherWithFunc[T comparable](capacity int, serializer Serializer[T], newHash func() hash.Hash) *CachedHasher[T] {
+	return &CachedHasher[T]{
+		serializer: serializer,
+		cache:      NewCache[T, Hash](capacity),
+		pool:       newHasherPoolWithFunc(newHash),
+	}
+}
+
+// NewKeccak256CachedHasher is a convenience constructor equivalent to
+// NewCachedHasher.
+func NewKeccak256CachedHasher[T comparable](capacity int, serializer Serializer[T]) *CachedHasher[T] {
+	return NewCachedHasherWithFunc(capacity, serializer, NewKeccak256Hasher)
+}
+
+// NewSha256CachedHasher creates a CachedHasher hashing with SHA-256.
+func NewSha256CachedHasher[T comparable](capacity int, serializer Serializer[T]) *CachedHasher[T] {
+	return NewCachedHasherWithFunc(capacity, serializer, NewSha256Hasher)
+}
+
+// NewBlake2bCachedHasher creates a CachedHasher hashing with Blake2b-256.
+func NewBlake2bCachedHasher[T comparable](capacity int, serializer Serializer[T]) *CachedHasher[T] {
+	return NewCachedHasherWithFunc(capacity, serializer, NewBlake2bHasher)
+}
+
+// Hash returns the cached hash of value, computing and caching it on a
+// miss.
+func (h *CachedHasher[T]) Hash(value T) Hash {
+	h.cacheMu.Lock()
+	cached, found := h.cache.Get(value)
+	h.cacheMu.Unlock()
+	if found {
+		return cached
+	}
+
+	hasher := h.pool.getHasher()
+	result := GetHash(hasher, h.serializer.ToBytes(value))
+	h.pool.returnHasher(hasher)
+
+	h.cacheMu.Lock()
+	h.cache.Set(value, result)
+	h.cacheMu.Unlock()
+	return result
+}
+
+// GetMemoryFootprint provides sizes of individual components of the
+// hasher in memory.
+func (h *CachedHasher[T]) GetMemoryFootprint() *MemoryFootprint {
+	mf := NewMemoryFootprint(unsafe.Sizeof(*h))
+	var key T
+	var value Hash
+	mf.AddChild("cache", NewMemoryFootprint(uintptr(h.cache.Len())*(unsafe.Sizeof(key)+unsafe.Sizeof(value))))
+	mf.AddChild("hashersPool", NewMemoryFootprint(h.pool.GetMemoryFootprint()))
+	return mf
+}
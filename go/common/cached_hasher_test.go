@@ -2,6 +2,7 @@ package common
 
 import (
 	"golang.org/x/crypto/sha3"
+	"hash"
 	"testing"
 	"unsafe"
 )
@@ -67,6 +68,32 @@ func TestHasherPool(t *testing.T) {
 	}
 }
 
+func TestCachedHasher_SupportsAlternativeHashFunctions(t *testing.T) {
+	newHashers := map[string]func() hash.Hash{
+		"Keccak256": NewKeccak256Hasher,
+		"Sha256":    NewSha256Hasher,
+		"Blake2b":   NewBlake2bHasher,
+	}
+
+	for name, newHash := range newHashers {
+		t.Run(name, func(t *testing.T) {
+			hasher := NewCachedHasherWithFunc[Address](10, AddressSerializer{}, newHash)
+
+			var adr Address
+			want := GetHash(newHash(), adr[:])
+			if got := hasher.Hash(adr); got != want {
+				t.Errorf("hashes do not match: %v != %v", got, want)
+			}
+
+			// repeated hashing of the same value must hit the cache and
+			// stay consistent with the direct computation.
+			if got := hasher.Hash(adr); got != want {
+				t.Errorf("cached hash does not match: %v != %v", got, want)
+			}
+		})
+	}
+}
+
 func TestMemoryFootprint(t *testing.T) {
 	cacheSize := 1000
 	hasher := NewCachedHasher[Address](cacheSize, AddressSerializer{})
@@ -1,6 +1,9 @@
 package common
 
-import "math/rand"
+import (
+	"math"
+	"math/rand"
+)
 
 // Distribution wraps a Label of the distribution and a function to get a next value withing the given distribution
 type Distribution struct {
@@ -8,10 +11,41 @@ type Distribution struct {
 	GetNext func() uint32
 }
 
+// zipfianSkew is the default exponent used by the Zipfian distribution
+// returned by GetDistributions, chosen to match the classic access-skew
+// constant (e.g. Zipf's law for word/page popularity) rather than
+// something degenerate like a uniform (s=0) or near-single-key (s>>1)
+// distribution.
+const zipfianSkew = 1.2
+
+// hotspotFraction and hotspotKeyFraction are the default parameters of the
+// Hotspot distribution returned by GetDistributions: hotspotFraction of
+// accesses land on hotspotKeyFraction of the keyspace, the rest spread
+// uniformly over the whole keyspace - a simple, commonly used stand-in for
+// workloads where a handful of contract storage slots (e.g. a token's
+// total-supply or a popular AMM pool's reserves) absorb most traffic.
+const (
+	hotspotFraction    = 0.9
+	hotspotKeyFraction = 0.1
+)
+
 // GetDistributions return a set of distributions
+//
+// Note: exercising these against the MPT forest's CacheCapacity (to
+// validate LRU behaviour under skewed access) belongs in a forest
+// benchmark; Forest/ForestConfig have no defining source in this
+// repository snapshot, so mpt.BenchmarkNodeCache_Distributions exercises
+// them against newNodeCache (see forest_cache_strategy.go) instead - the
+// cache component CacheCapacity would configure - until a real forest
+// benchmark is possible.
 func GetDistributions(size int) []Distribution {
 	expRate := float64(10) / float64(size)
 	it := 0
+	zipf := newZipfGenerator(size, zipfianSkew)
+	hotKeys := int(float64(size) * hotspotKeyFraction)
+	if hotKeys < 1 {
+		hotKeys = 1
+	}
 	return []Distribution{
 		{
 			Label: "Sequential",
@@ -32,5 +66,91 @@ func GetDistributions(size int) []Distribution {
 				return uint32(rand.ExpFloat64() / expRate)
 			},
 		},
+		{
+			Label: "Zipfian",
+			GetNext: func() uint32 {
+				return uint32(zipf.next() - 1)
+			},
+		},
+		{
+			Label: "Hotspot",
+			GetNext: func() uint32 {
+				if rand.Float64() < hotspotFraction {
+					return uint32(rand.Intn(hotKeys))
+				}
+				return uint32(rand.Intn(size))
+			},
+		},
 	}
-}
\ No newline at end of file
+}
+
+// zipfGenerator draws integers from {1,...,n} with probability
+// proportional to k^-s using rejection sampling against an envelope built
+// from the continuous antiderivative of x^-s (hIntegral below), so that
+// only a single O(1) precomputed scalar (hN) is needed rather than an
+// O(n) cumulative-distribution table: for a decreasing function such as
+// x^-s, the integral of the function over the unit interval ending at an
+// integer k is never smaller than the function's value at k itself, so
+// that integral is a valid (if occasionally loose) upper bound on the
+// discrete weight k^-s and can be used as a rejection-sampling envelope.
+type zipfGenerator struct {
+	n  int
+	s  float64
+	hN float64 // hIntegral(n): total envelope mass for k in {2,...,n}
+}
+
+// newZipfGenerator creates a generator over {1,...,n} with skew s. s must
+// be positive; s == 1 is handled exactly (hIntegral degenerates to a
+// logarithm in that case).
+func newZipfGenerator(n int, s float64) *zipfGenerator {
+	return &zipfGenerator{n: n, s: s, hN: hIntegral(float64(n), s)}
+}
+
+// hIntegral is the antiderivative of x^-s from 1 to x: (x^(1-s)-1)/(1-s)
+// for s != 1, or ln(x) in the s == 1 limit.
+func hIntegral(x, s float64) float64 {
+	if math.Abs(s-1) < 1e-9 {
+		return math.Log(x)
+	}
+	return (math.Pow(x, 1-s) - 1) / (1 - s)
+}
+
+// hIntegralInverse inverts hIntegral with respect to x for a fixed s.
+func hIntegralInverse(u, s float64) float64 {
+	if math.Abs(s-1) < 1e-9 {
+		return math.Exp(u)
+	}
+	return math.Pow(1+(1-s)*u, 1/(1-s))
+}
+
+// next draws a single sample in {1,...,n}.
+func (z *zipfGenerator) next() int {
+	if z.n <= 1 {
+		return 1
+	}
+	total := 1 + z.hN // bucket {1} has exact mass 1; buckets {2,...,n} have mass hN in aggregate
+	for {
+		u := rand.Float64() * total
+		if u <= 1 {
+			return 1
+		}
+
+		x := hIntegralInverse(u-1, z.s)
+		k := int(math.Ceil(x))
+		if k < 2 {
+			k = 2
+		}
+		if k > z.n {
+			k = z.n
+		}
+
+		envelope := hIntegral(float64(k), z.s) - hIntegral(float64(k-1), z.s)
+		if envelope <= 0 {
+			continue
+		}
+		weight := math.Pow(float64(k), -z.s)
+		if rand.Float64()*envelope <= weight {
+			return k
+		}
+	}
+}
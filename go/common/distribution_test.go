@@ -0,0 +1,73 @@
+package common
+
+import "testing"
+
+func TestGetDistributions_CoversExpectedLabels(t *testing.T) {
+	want := map[string]bool{
+		"Sequential":  true,
+		"Uniform":     true,
+		"Exponential": true,
+		"Zipfian":     true,
+		"Hotspot":     true,
+	}
+	for _, d := range GetDistributions(100) {
+		if !want[d.Label] {
+			t.Errorf("unexpected distribution label %q", d.Label)
+		}
+		delete(want, d.Label)
+	}
+	if len(want) != 0 {
+		t.Errorf("missing distributions: %v", want)
+	}
+}
+
+func TestGetDistributions_ValuesStayInRange(t *testing.T) {
+	const size = 50
+	for _, d := range GetDistributions(size) {
+		if d.Label == "Exponential" {
+			// Exponential is unbounded above: it can exceed size by design.
+			continue
+		}
+		for i := 0; i < 1000; i++ {
+			v := d.GetNext()
+			if v >= uint32(size) {
+				t.Fatalf("%s: value %d out of range [0,%d)", d.Label, v, size)
+			}
+		}
+	}
+}
+
+func TestZipfGenerator_StaysInRange(t *testing.T) {
+	z := newZipfGenerator(100, zipfianSkew)
+	for i := 0; i < 5000; i++ {
+		v := z.next()
+		if v < 1 || v > 100 {
+			t.Fatalf("sample %d out of range [1,100]", v)
+		}
+	}
+}
+
+func TestZipfGenerator_FavorsLowerRanks(t *testing.T) {
+	z := newZipfGenerator(100, zipfianSkew)
+	var lowCount, highCount int
+	for i := 0; i < 5000; i++ {
+		v := z.next()
+		if v <= 10 {
+			lowCount++
+		} else {
+			highCount++
+		}
+	}
+	if lowCount <= highCount {
+		t.Errorf("expected skewed distribution to favor the first 10 of 100 ranks, got low=%d high=%d", lowCount, highCount)
+	}
+}
+
+func TestZipfGenerator_DegenerateSizeIsStable(t *testing.T) {
+	z := newZipfGenerator(1, zipfianSkew)
+	for i := 0; i < 10; i++ {
+		if v := z.next(); v != 1 {
+			t.Errorf("expected the only possible sample to be 1, got %d", v)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package common
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// LockMode selects the semantics of a lock acquired via CreateLockFileMode:
+// Exclusive blocks any other lock, exclusive or shared, on the same path;
+// Shared coexists with other Shared locks on the same path but blocks
+// Exclusive acquisition, mirroring the reader/writer semantics of
+// WebDAV-style lock tokens.
+type LockMode int
+
+const (
+	Exclusive LockMode = iota
+	Shared
+)
+
+// LockOwner describes who currently holds a lock file: written when the
+// lock is acquired and refreshed on every heartbeat while it is held, so
+// that another process can tell a live lock from one abandoned by a
+// crashed owner.
+type LockOwner struct {
+	Pid       int
+	Hostname  string
+	StartedAt time.Time
+	Heartbeat time.Time
+}
+
+// LockFile represents a lock held on a path. Refresh extends its
+// heartbeat immediately instead of waiting for the next background tick;
+// Owner reports the metadata currently persisted for it; Release gives it
+// up. A held LockFile is not automatically released when the process
+// terminates.
+type LockFile interface {
+	Refresh() error
+	Owner() (LockOwner, error)
+	Release() error
+}
+
+// ErrLockHeld is returned by CreateLockFileMode when path is already
+// locked by a live (non-stale) owner in a way that conflicts with the
+// requested mode.
+var ErrLockHeld = errors.New("path is already locked")
+
+// ErrLockStale is returned by CreateLockFileMode when the only conflicting
+// lock found is stale (its heartbeat is older than staleTTL): the caller
+// must explicitly call BreakStaleLock before retrying, rather than having
+// acquisition silently take over a lock that might belong to a process
+// that is merely slow, not dead.
+var ErrLockStale = errors.New("existing lock is stale")
+
+const (
+	defaultStaleTTL          = 30 * time.Second
+	defaultHeartbeatInterval = 5 * time.Second
+)
+
+const sharedLockGlob = ".shared-"
+
+// CreateLockFile acquires an Exclusive lock at path with the default stale
+// TTL, for callers that do not need shared access or a custom TTL.
+func CreateLockFile(path string) (LockFile, error) {
+	return CreateLockFileMode(path, Exclusive, 0)
+}
+
+// CreateLockFileMode acquires a lock at path under mode. staleTTL bounds
+// how old another lock's heartbeat may be before it is still considered
+// live; a staleTTL of zero or less selects a default. If a conflicting
+// lock is found and is stale, ErrLockStale is returned instead of
+// ErrLockHeld so the caller can choose to call BreakStaleLock and retry.
+func CreateLockFileMode(path string, mode LockMode, staleTTL time.Duration) (LockFile, error) {
+	if staleTTL <= 0 {
+		staleTTL = defaultStaleTTL
+	}
+
+	switch mode {
+	case Exclusive:
+		if err := checkNoConflictingLock(path, staleTTL); err != nil {
+			return nil, err
+		}
+		file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+		if err != nil {
+			if os.IsExist(err) {
+				return nil, fmt.Errorf("%w: %s", ErrLockHeld, path)
+			}
+			return nil, err
+		}
+		return newFileLock(file, path), nil
+	case Shared:
+		if owner, found, err := readOwner(path); err != nil {
+			return nil, err
+		} else if found {
+			if isStale(owner, staleTTL) {
+				return nil, fmt.Errorf("%w: %s", ErrLockStale, path)
+			}
+			return nil, fmt.Errorf("%w: %s", ErrLockHeld, path)
+		}
+		sharedPath := fmt.Sprintf("%s%s%d-%d", path, sharedLockGlob, os.Getpid(), time.Now().UnixNano())
+		file, err := os.OpenFile(sharedPath, os.O_CREATE|os.O_EXCL|os.O_RDWR, 0600)
+		if err != nil {
+			return nil, err
+		}
+		return newFileLock(file, sharedPath), nil
+	default:
+		return nil, fmt.Errorf("unknown lock mode %d", mode)
+	}
+}
+
+// checkNoConflictingLock returns ErrLockHeld/ErrLockStale if path's
+// exclusive marker or any of its shared markers represent a live or stale
+// lock respectively, and nil if path is free to be exclusively acquired.
+func checkNoConflictingLock(path string, staleTTL time.Duration) error {
+	if owner, found, err := readOwner(path); err != nil {
+		return err
+	} else if found {
+		if isStale(owner, staleTTL) {
+			return fmt.Errorf("%w: %s", ErrLockStale, path)
+		}
+		return fmt.Errorf("%w: %s", ErrLockHeld, path)
+	}
+
+	matches, err := filepath.Glob(path + sharedLockGlob + "*")
+	if err != nil {
+		return err
+	}
+	for _, match := range matches {
+		owner, found, err := readOwner(match)
+		if err != nil || !found {
+			continue
+		}
+		if isStale(owner, staleTTL) {
+			return fmt.Errorf("%w: %s", ErrLockStale, match)
+		}
+		return fmt.Errorf("%w: %s", ErrLockHeld, match)
+	}
+	return nil
+}
+
+// BreakStaleLock removes path's exclusive marker and every shared marker
+// found for it, provided every one of them is stale under staleTTL. It
+// refuses and returns ErrLockHeld if any of them is still live, so a
+// misdiagnosed "stale" lock cannot be broken out from under its owner. A
+// staleTTL of zero or less selects the same default as CreateLockFileMode.
+func BreakStaleLock(path string, staleTTL time.Duration) error {
+	if staleTTL <= 0 {
+		staleTTL = defaultStaleTTL
+	}
+
+	candidates := []string{path}
+	matches, err := filepath.Glob(path + sharedLockGlob + "*")
+	if err != nil {
+		return err
+	}
+	candidates = append(candidates, matches...)
+
+	var toRemove []string
+	for _, candidate := range candidates {
+		owner, found, err := readOwner(candidate)
+		if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+		if !isStale(owner, staleTTL) {
+			return fmt.Errorf("%w: %s", ErrLockHeld, candidate)
+		}
+		toRemove = append(toRemove, candidate)
+	}
+	for _, candidate := range toRemove {
+		if err := os.Remove(candidate); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func isStale(owner LockOwner, staleTTL time.Duration) bool {
+	return time.Since(owner.Heartbeat) > staleTTL
+}
+
+func readOwner(path string) (LockOwner, bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return LockOwner{}, false, nil
+		}
+		return LockOwner{}, false, err
+	}
+	if len(data) == 0 {
+		// A lock file whose owner metadata has not been written yet (a
+		// narrow race right after creation) is treated as held by a live
+		// owner, never as absent or stale.
+		return LockOwner{Heartbeat: time.Now()}, true, nil
+	}
+	var owner LockOwner
+	if err := json.Unmarshal(data, &owner); err != nil {
+		return LockOwner{}, false, fmt.Errorf("corrupted lock file %s: %w", path, err)
+	}
+	return owner, true, nil
+}
+
+// fileLock is the common.LockFile implementation returned by
+// CreateLockFileMode: it owns a heartbeat goroutine that keeps its owner
+// metadata fresh on disk until Release is called.
+type fileLock struct {
+	path string
+	file *os.File
+
+	mu     sync.Mutex
+	closed bool
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func newFileLock(file *os.File, path string) *fileLock {
+	l := &fileLock{path: path, file: file, stop: make(chan struct{}), done: make(chan struct{})}
+	_ = l.writeOwner(time.Now())
+	go l.runHeartbeat()
+	return l
+}
+
+func (l *fileLock) writeOwner(startedAt time.Time) error {
+	hostname, _ := os.Hostname()
+	owner := LockOwner{Pid: os.Getpid(), Hostname: hostname, StartedAt: startedAt, Heartbeat: time.Now()}
+	data, err := json.Marshal(owner)
+	if err != nil {
+		return err
+	}
+	if _, err := l.file.WriteAt(data, 0); err != nil {
+		return err
+	}
+	return l.file.Truncate(int64(len(data)))
+}
+
+func (l *fileLock) runHeartbeat() {
+	defer close(l.done)
+	ticker := time.NewTicker(defaultHeartbeatInterval)
+	defer ticker.Stop()
+	started, _, _ := readOwner(l.path)
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			l.mu.Lock()
+			if !l.closed {
+				_ = l.writeOwner(started.StartedAt)
+			}
+			l.mu.Unlock()
+		}
+	}
+}
+
+// Refresh immediately updates the lock's heartbeat on disk instead of
+// waiting for the next background tick.
+func (l *fileLock) Refresh() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return fmt.Errorf("lock %s already released", l.path)
+	}
+	owner, _, err := readOwner(l.path)
+	if err != nil {
+		return err
+	}
+	return l.writeOwner(owner.StartedAt)
+}
+
+// Owner reports the metadata currently persisted for this lock.
+func (l *fileLock) Owner() (LockOwner, error) {
+	owner, found, err := readOwner(l.path)
+	if err != nil {
+		return LockOwner{}, err
+	}
+	if !found {
+		return LockOwner{}, fmt.Errorf("lock %s not found", l.path)
+	}
+	return owner, nil
+}
+
+// Release stops the heartbeat goroutine, closes, and removes the lock
+// file.
+func (l *fileLock) Release() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	close(l.stop)
+	<-l.done
+
+	if err := l.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
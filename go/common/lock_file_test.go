@@ -0,0 +1,155 @@
+package common
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockFile_ExclusiveBlocksExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire exclusive lock: %v", err)
+	}
+	defer lock.Release()
+
+	if _, err := CreateLockFileMode(path, Exclusive, time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("expected ErrLockHeld, got %v", err)
+	}
+}
+
+func TestLockFile_SharedCoexistsWithShared(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock1, err := CreateLockFileMode(path, Shared, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire first shared lock: %v", err)
+	}
+	defer lock1.Release()
+
+	lock2, err := CreateLockFileMode(path, Shared, time.Minute)
+	if err != nil {
+		t.Fatalf("expected a second shared lock to succeed, got %v", err)
+	}
+	defer lock2.Release()
+}
+
+func TestLockFile_SharedBlocksExclusiveAndViceVersa(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	shared, err := CreateLockFileMode(path, Shared, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire shared lock: %v", err)
+	}
+	defer shared.Release()
+
+	if _, err := CreateLockFileMode(path, Exclusive, time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("expected exclusive acquisition to be blocked by a live shared lock, got %v", err)
+	}
+
+	path2 := filepath.Join(t.TempDir(), "lock")
+	exclusive, err := CreateLockFileMode(path2, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire exclusive lock: %v", err)
+	}
+	defer exclusive.Release()
+
+	if _, err := CreateLockFileMode(path2, Shared, time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("expected shared acquisition to be blocked by a live exclusive lock, got %v", err)
+	}
+}
+
+func TestLockFile_StaleLockIsReportedAndCanBeBroken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire exclusive lock: %v", err)
+	}
+	defer lock.Release()
+
+	// A vanishingly small TTL treats any already-elapsed heartbeat as
+	// stale, simulating staleness without waiting out a real heartbeat
+	// interval.
+	if _, err := CreateLockFileMode(path, Exclusive, time.Nanosecond); !errors.Is(err, ErrLockStale) {
+		t.Fatalf("expected ErrLockStale, got %v", err)
+	}
+
+	if err := BreakStaleLock(path, time.Nanosecond); err != nil {
+		t.Fatalf("failed to break stale lock: %v", err)
+	}
+
+	relocked, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("expected to reacquire the lock after breaking it, got %v", err)
+	}
+	defer relocked.Release()
+}
+
+func TestLockFile_BreakStaleLockRefusesToBreakLiveLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire exclusive lock: %v", err)
+	}
+	defer lock.Release()
+
+	if err := BreakStaleLock(path, time.Minute); !errors.Is(err, ErrLockHeld) {
+		t.Errorf("expected BreakStaleLock to refuse a live lock, got %v", err)
+	}
+}
+
+func TestLockFile_RefreshAndOwner(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire exclusive lock: %v", err)
+	}
+	defer lock.Release()
+
+	before := mustOwner(t, lock)
+	time.Sleep(time.Millisecond)
+	if err := lock.Refresh(); err != nil {
+		t.Fatalf("failed to refresh lock: %v", err)
+	}
+	after := mustOwner(t, lock)
+
+	if !after.Heartbeat.After(before.Heartbeat) {
+		t.Errorf("expected Refresh to advance the heartbeat: before %v, after %v", before.Heartbeat, after.Heartbeat)
+	}
+	if after.Pid == 0 {
+		t.Errorf("expected owner Pid to be populated")
+	}
+}
+
+func TestLockFile_ReleaseRemovesLockFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lock")
+
+	lock, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("failed to acquire exclusive lock: %v", err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("failed to release lock: %v", err)
+	}
+
+	relocked, err := CreateLockFileMode(path, Exclusive, time.Minute)
+	if err != nil {
+		t.Fatalf("expected to reacquire the lock after release, got %v", err)
+	}
+	defer relocked.Release()
+}
+
+func mustOwner(t *testing.T, lock LockFile) LockOwner {
+	t.Helper()
+	owner, err := lock.Owner()
+	if err != nil {
+		t.Fatalf("failed to read lock owner: %v", err)
+	}
+	return owner
+}
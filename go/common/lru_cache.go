@@ -0,0 +1,147 @@
+package common
+
+import "fmt"
+
+// MissHitMeasuring toggles hit/miss counting on every Cache. It is off by
+// default since the counters add overhead to every Get; enable it (e.g.
+// from a benchmark's init) to obtain a getHitRatioReport().
+var MissHitMeasuring = false
+
+// Cache is a fixed-capacity, in-memory least-recently-used cache: Get
+// promotes the accessed entry to the most-recently-used end, and Set
+// evicts the least-recently-used entry once the number of distinct keys
+// would exceed capacity.
+type Cache[K comparable, V any] struct {
+	capacity int
+	index    map[K]*cacheEntry[K, V]
+	head     *cacheEntry[K, V] // most recently used
+	tail     *cacheEntry[K, V] // least recently used
+
+	hits   int
+	misses int
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key   K
+	value V
+	prev  *cacheEntry[K, V]
+	next  *cacheEntry[K, V]
+}
+
+// NewCache creates an empty Cache holding up to capacity distinct keys.
+func NewCache[K comparable, V any](capacity int) *Cache[K, V] {
+	return &Cache[K, V]{
+		capacity: capacity,
+		index:    make(map[K]*cacheEntry[K, V], capacity),
+	}
+}
+
+// Len reports the number of distinct keys currently held.
+func (c *Cache[K, V]) Len() int {
+	return len(c.index)
+}
+
+// Get retrieves the value for key, promoting it to most-recently-used.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	entry, exists := c.index[key]
+	if !exists {
+		if MissHitMeasuring {
+			c.misses++
+		}
+		var zero V
+		return zero, false
+	}
+	if MissHitMeasuring {
+		c.hits++
+	}
+	c.moveToFront(entry)
+	return entry.value, true
+}
+
+// Set adds or updates key's value, promoting it to most-recently-used. If
+// adding key as a new entry would exceed capacity, the least-recently-used
+// entry is evicted and returned.
+func (c *Cache[K, V]) Set(key K, value V) (evictedKey K, evictedValue V, evicted bool) {
+	if entry, exists := c.index[key]; exists {
+		entry.value = value
+		c.moveToFront(entry)
+		return evictedKey, evictedValue, false
+	}
+
+	entry := &cacheEntry[K, V]{key: key, value: value}
+	c.index[key] = entry
+	c.pushFront(entry)
+
+	if len(c.index) > c.capacity {
+		lru := c.tail
+		c.remove(lru)
+		delete(c.index, lru.key)
+		return lru.key, lru.value, true
+	}
+	return evictedKey, evictedValue, false
+}
+
+// Keys returns the cached keys in most-recently-used-first order.
+func (c *Cache[K, V]) Keys() []K {
+	keys := make([]K, 0, len(c.index))
+	for entry := c.head; entry != nil; entry = entry.next {
+		keys = append(keys, entry.key)
+	}
+	return keys
+}
+
+// Delete removes key, if present, reporting whether it was found.
+func (c *Cache[K, V]) Delete(key K) bool {
+	entry, exists := c.index[key]
+	if !exists {
+		return false
+	}
+	c.remove(entry)
+	delete(c.index, key)
+	return true
+}
+
+// getHitRatioReport summarizes the hit/miss counters collected while
+// MissHitMeasuring was enabled.
+func (c *Cache[K, V]) getHitRatioReport() string {
+	total := c.hits + c.misses
+	ratio := 0.0
+	if total > 0 {
+		ratio = float64(c.hits) / float64(total)
+	}
+	return fmt.Sprintf("(misses: %d, hits: %d, hitRatio: %f)", c.misses, c.hits, ratio)
+}
+
+func (c *Cache[K, V]) pushFront(entry *cacheEntry[K, V]) {
+	entry.prev = nil
+	entry.next = c.head
+	if c.head != nil {
+		c.head.prev = entry
+	}
+	c.head = entry
+	if c.tail == nil {
+		c.tail = entry
+	}
+}
+
+func (c *Cache[K, V]) remove(entry *cacheEntry[K, V]) {
+	if entry.prev != nil {
+		entry.prev.next = entry.next
+	} else {
+		c.head = entry.next
+	}
+	if entry.next != nil {
+		entry.next.prev = entry.prev
+	} else {
+		c.tail = entry.prev
+	}
+	entry.prev, entry.next = nil, nil
+}
+
+func (c *Cache[K, V]) moveToFront(entry *cacheEntry[K, V]) {
+	if c.head == entry {
+		return
+	}
+	c.remove(entry)
+	c.pushFront(entry)
+}
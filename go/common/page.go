@@ -0,0 +1,5 @@
+package common
+
+// PageSize is the default byte capacity of a single page managed by a
+// pagepool.PagePool.
+const PageSize = 4096
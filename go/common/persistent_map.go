@@ -0,0 +1,118 @@
+package common
+
+// PersistentMap is an immutable, structurally-shared hash trie: Set returns
+// a new map that reuses every part of the trie the update did not touch,
+// so a chain of snapshots derived from one another via repeated Set calls
+// shares memory for all the nodes they have in common instead of each
+// holding a full copy, and once a snapshot is no longer referenced only the
+// nodes unique to it become collectible. This is the immutable-mode
+// counterpart to Cache's LRU eviction, for workloads - e.g. an archive
+// repeatedly querying old, frozen roots, as in
+// mpt.TestForest_InArchiveModeHistoryIsPreserved - where a once-built
+// lookup structure should never be evicted, only cheaply forked from the
+// parent snapshot it is built on top of.
+//
+// This is a fixed-width (32-ary) variant rather than a bitmap-compacted
+// HAMT: every internal node holds a full array of 32 child pointers, most
+// of them nil, trading some memory for a considerably simpler, easier to
+// verify implementation.
+type PersistentMap[K comparable, V any] struct {
+	hash func(K) uint64
+	root *hamtNode[K, V]
+	size int
+}
+
+const (
+	hamtBits  = 5
+	hamtWidth = 1 << hamtBits
+	hamtMask  = hamtWidth - 1
+)
+
+type hamtEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+// hamtNode is either an internal node (children != nil) or a leaf bucket
+// (entries != nil, holding every key so far found to hash identically); a
+// node is never both, and an empty PersistentMap has a nil root.
+type hamtNode[K comparable, V any] struct {
+	children [hamtWidth]*hamtNode[K, V]
+	entries  []hamtEntry[K, V]
+}
+
+// NewPersistentMap creates an empty PersistentMap using hash to place keys
+// within the trie. Keys that compare equal must hash identically; unequal
+// keys may hash identically, in which case they share a leaf bucket.
+func NewPersistentMap[K comparable, V any](hash func(K) uint64) *PersistentMap[K, V] {
+	return &PersistentMap[K, V]{hash: hash}
+}
+
+// Len returns the number of distinct keys mapped.
+func (m *PersistentMap[K, V]) Len() int {
+	return m.size
+}
+
+// Get returns the value mapped to key, if any.
+func (m *PersistentMap[K, V]) Get(key K) (V, bool) {
+	h := m.hash(key)
+	node := m.root
+	for node != nil {
+		if node.entries != nil {
+			for _, e := range node.entries {
+				if e.key == key {
+					return e.value, true
+				}
+			}
+			var zero V
+			return zero, false
+		}
+		node = node.children[h&hamtMask]
+		h >>= hamtBits
+	}
+	var zero V
+	return zero, false
+}
+
+// Set returns a new PersistentMap with key mapped to value. Every node on
+// the path from the root to key's leaf bucket is copied; every other node
+// of the trie is shared, unchanged, with the receiver.
+func (m *PersistentMap[K, V]) Set(key K, value V) *PersistentMap[K, V] {
+	newRoot, grew := setHamtNode(m.root, m.hash, 0, key, value)
+	size := m.size
+	if grew {
+		size++
+	}
+	return &PersistentMap[K, V]{hash: m.hash, root: newRoot, size: size}
+}
+
+func setHamtNode[K comparable, V any](node *hamtNode[K, V], hash func(K) uint64, shift uint, key K, value V) (*hamtNode[K, V], bool) {
+	if node == nil {
+		return &hamtNode[K, V]{entries: []hamtEntry[K, V]{{key, value}}}, true
+	}
+	if node.entries != nil {
+		for i, e := range node.entries {
+			if e.key == key {
+				entries := append([]hamtEntry[K, V]{}, node.entries...)
+				entries[i] = hamtEntry[K, V]{key, value}
+				return &hamtNode[K, V]{entries: entries}, false
+			}
+		}
+		bucketHash := hash(node.entries[0].key)
+		if bucketHash == hash(key) {
+			entries := append(append([]hamtEntry[K, V]{}, node.entries...), hamtEntry[K, V]{key, value})
+			return &hamtNode[K, V]{entries: entries}, true
+		}
+		// key hashes differently: push this bucket one level down and insert
+		// key into the new internal node beside it.
+		split := &hamtNode[K, V]{}
+		split.children[(bucketHash>>shift)&hamtMask] = node
+		return setHamtNode(split, hash, shift, key, value)
+	}
+
+	idx := (hash(key) >> shift) & hamtMask
+	child, grew := setHamtNode(node.children[idx], hash, shift+hamtBits, key, value)
+	children := node.children
+	children[idx] = child
+	return &hamtNode[K, V]{children: children}, grew
+}
@@ -0,0 +1,76 @@
+package common
+
+import "testing"
+
+func hashUint64(k uint64) uint64 { return k }
+
+func TestPersistentMap_SetAndGet(t *testing.T) {
+	m := NewPersistentMap[uint64, string](hashUint64)
+	m2 := m.Set(1, "a")
+
+	if _, found := m.Get(1); found {
+		t.Errorf("original map should not observe a later Set")
+	}
+	if got, found := m2.Get(1); !found || got != "a" {
+		t.Errorf("expected to find 'a', got %v, found %t", got, found)
+	}
+	if _, found := m2.Get(2); found {
+		t.Errorf("unexpected hit for missing key")
+	}
+}
+
+func TestPersistentMap_SetSharesStructureAcrossSnapshots(t *testing.T) {
+	m := NewPersistentMap[uint64, string](hashUint64)
+	for i := uint64(0); i < 100; i++ {
+		m = m.Set(i, "v")
+	}
+
+	fork1 := m.Set(100, "fork1")
+	fork2 := m.Set(100, "fork2")
+
+	for i := uint64(0); i < 100; i++ {
+		if got, found := fork1.Get(i); !found || got != "v" {
+			t.Errorf("fork1 lost key %d", i)
+		}
+		if got, found := fork2.Get(i); !found || got != "v" {
+			t.Errorf("fork2 lost key %d", i)
+		}
+	}
+	if got, _ := fork1.Get(100); got != "fork1" {
+		t.Errorf("fork1 should see its own write, got %q", got)
+	}
+	if got, _ := fork2.Get(100); got != "fork2" {
+		t.Errorf("fork2 should see its own write, got %q", got)
+	}
+	if _, found := m.Get(100); found {
+		t.Errorf("parent snapshot should not observe either fork's write")
+	}
+}
+
+func TestPersistentMap_LenCountsDistinctKeys(t *testing.T) {
+	m := NewPersistentMap[uint64, string](hashUint64)
+	m = m.Set(1, "a")
+	m = m.Set(2, "b")
+	m = m.Set(1, "a-updated")
+
+	if m.Len() != 2 {
+		t.Errorf("expected 2 distinct keys, got %d", m.Len())
+	}
+}
+
+func TestPersistentMap_HandlesHashCollisions(t *testing.T) {
+	constantHash := func(uint64) uint64 { return 0 }
+	m := NewPersistentMap[uint64, string](constantHash)
+	m = m.Set(1, "a")
+	m = m.Set(2, "b")
+
+	if got, found := m.Get(1); !found || got != "a" {
+		t.Errorf("expected 'a', got %v, found %t", got, found)
+	}
+	if got, found := m.Get(2); !found || got != "b" {
+		t.Errorf("expected 'b', got %v, found %t", got, found)
+	}
+	if m.Len() != 2 {
+		t.Errorf("expected 2 distinct keys despite colliding hashes, got %d", m.Len())
+	}
+}
@@ -0,0 +1,55 @@
+package common
+
+import (
+	"runtime"
+	"sync"
+)
+
+// WorkerPool bounds the number of goroutines running submitted tasks
+// concurrently to maxConcurrency, so that fanning work out over a large or
+// unbounded list (e.g. every key in a wide branch node) cannot spawn one
+// goroutine per item and run the process out of memory. Tasks are started
+// in submission order but may complete out of order; Wait blocks until
+// every submitted task has returned and reports the first error any of
+// them produced, if any.
+type WorkerPool struct {
+	limit chan struct{}
+	wg    sync.WaitGroup
+
+	mu       sync.Mutex
+	firstErr error
+}
+
+// NewWorkerPool creates a WorkerPool that runs at most maxConcurrency tasks
+// at a time. A maxConcurrency of zero or less selects runtime.GOMAXPROCS(0).
+func NewWorkerPool(maxConcurrency int) *WorkerPool {
+	if maxConcurrency <= 0 {
+		maxConcurrency = runtime.GOMAXPROCS(0)
+	}
+	return &WorkerPool{limit: make(chan struct{}, maxConcurrency)}
+}
+
+// Submit schedules task to run, blocking the caller only long enough to
+// acquire a free slot if the pool is already at maxConcurrency.
+func (p *WorkerPool) Submit(task func() error) {
+	p.limit <- struct{}{}
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		defer func() { <-p.limit }()
+		if err := task(); err != nil {
+			p.mu.Lock()
+			if p.firstErr == nil {
+				p.firstErr = err
+			}
+			p.mu.Unlock()
+		}
+	}()
+}
+
+// Wait blocks until every submitted task has completed and returns the
+// first error any of them returned, or nil if all succeeded.
+func (p *WorkerPool) Wait() error {
+	p.wg.Wait()
+	return p.firstErr
+}
@@ -0,0 +1,62 @@
+package common
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWorkerPool_RunsAllTasks(t *testing.T) {
+	pool := NewWorkerPool(4)
+	var completed int32
+	for i := 0; i < 100; i++ {
+		pool.Submit(func() error {
+			atomic.AddInt32(&completed, 1)
+			return nil
+		})
+	}
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if completed != 100 {
+		t.Errorf("expected 100 completed tasks, got %d", completed)
+	}
+}
+
+func TestWorkerPool_BoundsConcurrency(t *testing.T) {
+	const limit = 3
+	pool := NewWorkerPool(limit)
+
+	var current, max int32
+	for i := 0; i < 50; i++ {
+		pool.Submit(func() error {
+			n := atomic.AddInt32(&current, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if n <= m || atomic.CompareAndSwapInt32(&max, m, n) {
+					break
+				}
+			}
+			atomic.AddInt32(&current, -1)
+			return nil
+		})
+	}
+	if err := pool.Wait(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if max > limit {
+		t.Errorf("observed concurrency %d exceeds configured limit %d", max, limit)
+	}
+}
+
+func TestWorkerPool_ReportsFirstError(t *testing.T) {
+	pool := NewWorkerPool(2)
+	wantErr := errors.New("boom")
+	pool.Submit(func() error { return nil })
+	pool.Submit(func() error { return wantErr })
+	pool.Submit(func() error { return nil })
+
+	if err := pool.Wait(); !errors.Is(err, wantErr) {
+		t.Errorf("expected %v, got %v", wantErr, err)
+	}
+}
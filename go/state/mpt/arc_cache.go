@@ -0,0 +1,289 @@
+package mpt
+
+import "unsafe"
+
+// arcCache is an Adaptive Replacement Cache (ARC): four lists are
+// maintained - T1/T2 hold the byte budget of currently cached entries
+// (T1 recency, T2 frequency), B1/B2 hold only the keys recently evicted
+// from T1/T2 respectively (ghost entries, used to size T1 against T2). The
+// target size p self-tunes towards recency on a B1 hit and towards
+// frequency on a B2 hit, so the cache adapts to the skew of the workload
+// without any external configuration beyond the total byte budget.
+//
+// This replaces a plain LRU for the code store (see code_store.go) because
+// a short burst of scans over cold code (e.g. verifying a chain of blocks)
+// should not evict the hot set of frequently executed contracts, which a
+// pure LRU is prone to under such "scan resistant" workloads.
+type arcCache[K comparable, V any] struct {
+	byteBudget int
+	sizeOf     func(V) int
+
+	p int // target size of T1, in bytes
+
+	t1     *arcList[K, V]
+	t2     *arcList[K, V]
+	b1     *arcList[K, struct{}]
+	b2     *arcList[K, struct{}]
+	byteOf map[K]int
+
+	// ghostByteOf remembers the byte size a key had at the moment it was
+	// evicted from T1/T2 into B1/B2 (see replace below), since B1/B2 hold
+	// no values of their own to size via sizeOf - without this, B1/B2
+	// would have no byte accounting at all to adapt p against.
+	ghostByteOf map[K]int
+}
+
+func newArcCache[K comparable, V any](byteBudget int, sizeOf func(V) int) *arcCache[K, V] {
+	return &arcCache[K, V]{
+		byteBudget:  byteBudget,
+		sizeOf:      sizeOf,
+		t1:          newArcList[K, V](),
+		t2:          newArcList[K, V](),
+		b1:          newArcList[K, struct{}](),
+		b2:          newArcList[K, struct{}](),
+		byteOf:      map[K]int{},
+		ghostByteOf: map[K]int{},
+	}
+}
+
+// Get returns the cached value for key, if present in T1 or T2. A T1 hit
+// promotes the entry to T2, since it has now been requested more than once.
+func (a *arcCache[K, V]) Get(key K) (V, bool) {
+	if value, found := a.t1.remove(key); found {
+		a.t2.pushFront(key, value)
+		return value, true
+	}
+	if value, found := a.t2.peek(key); found {
+		a.t2.moveToFront(key)
+		return value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// Set inserts or updates the cached value for key, adapting p and evicting
+// from T1/T2 as needed to respect the byte budget.
+func (a *arcCache[K, V]) Set(key K, value V) {
+	size := a.sizeOf(value)
+
+	if _, found := a.byteOf[key]; found {
+		a.t1.remove(key)
+		a.t2.pushFront(key, value) // already-seen key: treat as a re-reference.
+		a.byteOf[key] = size
+		a.evictIfNeeded()
+		return
+	}
+
+	if _, ghost := a.b1.peek(key); ghost {
+		a.p = minInt(a.byteBudget, a.p+maxInt(1, a.ghostSizeEstimate(a.b2)/maxInt(1, a.ghostSizeEstimate(a.b1))))
+		a.b1.remove(key)
+		delete(a.ghostByteOf, key)
+		a.replace(size)
+		a.t2.pushFront(key, value)
+	} else if _, ghost := a.b2.peek(key); ghost {
+		a.p = maxInt(0, a.p-maxInt(1, a.ghostSizeEstimate(a.b1)/maxInt(1, a.ghostSizeEstimate(a.b2))))
+		a.b2.remove(key)
+		delete(a.ghostByteOf, key)
+		a.replace(size)
+		a.t2.pushFront(key, value)
+	} else {
+		a.t1.pushFront(key, value)
+	}
+
+	a.byteOf[key] = size
+	a.evictIfNeeded()
+}
+
+// replace evicts a single entry from T1 or T2 into its ghost list according
+// to the current target size p, making room for a new entry of the given
+// size arriving in T2.
+func (a *arcCache[K, V]) replace(incoming int) {
+	if a.t1.size() > 0 && (a.sizeEstimate(a.t1) >= a.p || (a.sizeEstimate(a.t1) == a.p && incoming > 0)) {
+		if key, _, ok := a.t1.popBack(); ok {
+			a.ghostByteOf[key] = a.byteOf[key]
+			delete(a.byteOf, key)
+			a.b1.pushFront(key, struct{}{})
+		}
+	} else if key, _, ok := a.t2.popBack(); ok {
+		a.ghostByteOf[key] = a.byteOf[key]
+		delete(a.byteOf, key)
+		a.b2.pushFront(key, struct{}{})
+	}
+}
+
+// evictIfNeeded trims T1/T2 (and their ghost lists B1/B2, capped at the
+// same combined budget) until the cached byte total fits byteBudget.
+func (a *arcCache[K, V]) evictIfNeeded() {
+	for a.sizeEstimate(a.t1)+a.sizeEstimate(a.t2) > a.byteBudget {
+		a.replace(0)
+	}
+	for a.t1.size()+a.b1.size() > a.byteBudget {
+		if key, _, ok := a.b1.popBack(); ok {
+			delete(a.ghostByteOf, key)
+		} else {
+			break
+		}
+	}
+	for a.t2.size()+a.b2.size() > a.byteBudget {
+		if key, _, ok := a.b2.popBack(); ok {
+			delete(a.ghostByteOf, key)
+		} else {
+			break
+		}
+	}
+}
+
+// sizeEstimate sums the remembered byte size of every key currently in
+// list, for list == a.t1 or a.t2 (live entries, sized via a.byteOf - see
+// Set). B1/B2 hold no values to size this way; use ghostSizeEstimate for
+// those. Implemented via the standalone sizeEstimateBy so the byte-
+// accounting map can vary independently of list's own value type (V for
+// T1/T2, struct{} for B1/B2) - a single method parameterized only on the
+// receiver's V cannot do this, since *arcList[K, struct{}] and
+// *arcList[K, V] are different instantiations of the same generic type.
+func (a *arcCache[K, V]) sizeEstimate(list *arcList[K, V]) int {
+	return sizeEstimateBy(list, a.byteOf)
+}
+
+// ghostSizeEstimate sums the remembered byte size of every key currently
+// in a ghost list (a.b1 or a.b2), looked up from a.ghostByteOf rather than
+// a.byteOf.
+func (a *arcCache[K, V]) ghostSizeEstimate(list *arcList[K, struct{}]) int {
+	return sizeEstimateBy(list, a.ghostByteOf)
+}
+
+// sizeEstimateBy sums byteOf[key] for every key in list, in whatever order
+// keysInOrder returns them.
+func sizeEstimateBy[K comparable, V2 any](list *arcList[K, V2], byteOf map[K]int) int {
+	total := 0
+	for _, key := range list.keysInOrder() {
+		total += byteOf[key]
+	}
+	return total
+}
+
+// GetMemoryFootprint reports the real byte usage of the cached entries.
+func (a *arcCache[K, V]) GetMemoryFootprint() uintptr {
+	var total uintptr
+	for _, size := range a.byteOf {
+		total += uintptr(size)
+	}
+	return total + unsafe.Sizeof(*a)
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// arcList is a minimal doubly linked list with O(1) push-front, move-to-
+// front and remove-by-key, used to implement the four ARC lists T1/T2/B1/B2.
+type arcList[K comparable, V any] struct {
+	nodes map[K]*arcNode[K, V]
+	head  *arcNode[K, V]
+	tail  *arcNode[K, V]
+}
+
+type arcNode[K comparable, V any] struct {
+	key        K
+	value      V
+	prev, next *arcNode[K, V]
+}
+
+func newArcList[K comparable, V any]() *arcList[K, V] {
+	return &arcList[K, V]{nodes: map[K]*arcNode[K, V]{}}
+}
+
+func (l *arcList[K, V]) size() int { return len(l.nodes) }
+
+func (l *arcList[K, V]) pushFront(key K, value V) {
+	l.remove(key)
+	n := &arcNode[K, V]{key: key, value: value, next: l.head}
+	if l.head != nil {
+		l.head.prev = n
+	}
+	l.head = n
+	if l.tail == nil {
+		l.tail = n
+	}
+	l.nodes[key] = n
+}
+
+func (l *arcList[K, V]) moveToFront(key K) {
+	n, found := l.nodes[key]
+	if !found || n == l.head {
+		return
+	}
+	l.unlink(n)
+	n.prev, n.next = nil, l.head
+	if l.head != nil {
+		l.head.prev = n
+	}
+	l.head = n
+	if l.tail == nil {
+		l.tail = n
+	}
+}
+
+func (l *arcList[K, V]) unlink(n *arcNode[K, V]) {
+	if n.prev != nil {
+		n.prev.next = n.next
+	} else if l.head == n {
+		l.head = n.next
+	}
+	if n.next != nil {
+		n.next.prev = n.prev
+	} else if l.tail == n {
+		l.tail = n.prev
+	}
+	n.prev, n.next = nil, nil
+}
+
+func (l *arcList[K, V]) remove(key K) (V, bool) {
+	n, found := l.nodes[key]
+	if !found {
+		var zero V
+		return zero, false
+	}
+	l.unlink(n)
+	delete(l.nodes, key)
+	return n.value, true
+}
+
+func (l *arcList[K, V]) peek(key K) (V, bool) {
+	n, found := l.nodes[key]
+	if !found {
+		var zero V
+		return zero, false
+	}
+	return n.value, true
+}
+
+func (l *arcList[K, V]) popBack() (K, V, bool) {
+	if l.tail == nil {
+		var zeroKey K
+		var zeroValue V
+		return zeroKey, zeroValue, false
+	}
+	n := l.tail
+	l.unlink(n)
+	delete(l.nodes, n.key)
+	return n.key, n.value, true
+}
+
+func (l *arcList[K, V]) keysInOrder() []K {
+	keys := make([]K, 0, len(l.nodes))
+	for n := l.head; n != nil; n = n.next {
+		keys = append(keys, n.key)
+	}
+	return keys
+}
@@ -0,0 +1,101 @@
+package mpt
+
+import "testing"
+
+func TestArcCache_SetAndGet(t *testing.T) {
+	c := newArcCache[int, string](100, func(v string) int { return len(v) })
+
+	c.Set(1, "hello")
+	if got, found := c.Get(1); !found || got != "hello" {
+		t.Errorf("expected to find value, got %v, found %t", got, found)
+	}
+
+	if _, found := c.Get(2); found {
+		t.Errorf("unexpected hit for missing key")
+	}
+}
+
+func TestArcCache_T1HitPromotesToT2(t *testing.T) {
+	c := newArcCache[int, string](100, func(v string) int { return len(v) })
+
+	c.Set(1, "a")
+	if _, found := c.t2.peek(1); found {
+		t.Errorf("freshly inserted key should start in T1, not T2")
+	}
+
+	c.Get(1)
+	if _, found := c.t2.peek(1); !found {
+		t.Errorf("a T1 hit should promote the entry to T2")
+	}
+	if _, found := c.t1.peek(1); found {
+		t.Errorf("promoted entry should no longer be in T1")
+	}
+}
+
+func TestArcCache_EvictsUnderByteBudget(t *testing.T) {
+	// Each value is 10 bytes; a budget of 25 bytes should not fit all 5.
+	c := newArcCache[int, string](25, func(v string) int { return len(v) })
+
+	for i := 0; i < 5; i++ {
+		c.Set(i, "0123456789")
+	}
+
+	total := 0
+	for i := 0; i < 5; i++ {
+		if _, found := c.Get(i); found {
+			total++
+		}
+	}
+	if total*10 > 25 {
+		t.Errorf("cache retained more entries than its byte budget allows: %d entries", total)
+	}
+}
+
+func TestArcCache_BHitAdaptsPProportionallyToGhostListByteRatio(t *testing.T) {
+	c := newArcCache[int, string](1000, func(v string) int { return len(v) })
+
+	// Seed B1 with one small ghost entry and B2 with one large one directly,
+	// bypassing Set's normal flow, so the byte ratio between them is known
+	// exactly rather than depending on incidental sizes from prior Sets.
+	c.b1.pushFront(1, struct{}{})
+	c.ghostByteOf[1] = 10
+	c.b2.pushFront(2, struct{}{})
+	c.ghostByteOf[2] = 50
+	c.p = 0
+
+	// A B1 hit should grow p by the B2/B1 byte ratio (50/10 = 5), not by a
+	// fixed step of 1 as it would if ghost lists were never actually sized
+	// (sizeEstimate previously fell through to its default branch for
+	// *arcList[K, struct{}], always returning 0).
+	c.Set(1, "x")
+
+	if c.p != 5 {
+		t.Errorf("expected p to grow by the B2/B1 ghost-list byte ratio (5), got p=%d", c.p)
+	}
+}
+
+func TestArcCache_T2GhostHitAdaptsPProportionallyInTheOtherDirection(t *testing.T) {
+	c := newArcCache[int, string](1000, func(v string) int { return len(v) })
+
+	c.b1.pushFront(1, struct{}{})
+	c.ghostByteOf[1] = 50
+	c.b2.pushFront(2, struct{}{})
+	c.ghostByteOf[2] = 10
+	c.p = 20
+
+	// A B2 hit should shrink p by the B1/B2 byte ratio (50/10 = 5), to 15.
+	c.Set(2, "x")
+
+	if c.p != 15 {
+		t.Errorf("expected p to shrink by the B1/B2 ghost-list byte ratio (5) from 20 to 15, got p=%d", c.p)
+	}
+}
+
+func TestArcCache_MemoryFootprintReflectsUsage(t *testing.T) {
+	c := newArcCache[int, string](1000, func(v string) int { return len(v) })
+	c.Set(1, "0123456789")
+
+	if got := c.GetMemoryFootprint(); got < 10 {
+		t.Errorf("memory footprint too small for cached content: %d", got)
+	}
+}
@@ -0,0 +1,256 @@
+package mpt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// codeLocation is the index entry for a single stored contract code: its
+// position and length within the append-only segment file.
+type codeLocation struct {
+	offset int64
+	length uint32
+}
+
+// codeStore is a content-addressed, append-only store for contract
+// bytecode, fronted by an arcCache with a configurable byte budget. It
+// replaces loading the entire code corpus into memory on open and
+// rewriting it wholesale on every Flush (see readCodes/writeCodes), which
+// becomes untenable as the code corpus grows into GBs: GetCode/SetCode
+// become O(1) amortized cache lookups with on-demand disk reads, and Flush
+// only fsyncs the tail of the segment and index files.
+type codeStore struct {
+	directory   string
+	segmentFile *os.File
+	segmentSize int64
+
+	index map[common.Hash]codeLocation
+	cache *arcCache[common.Hash, []byte]
+
+	dirty bool
+}
+
+const (
+	codeStoreSegmentName = "codes.segment"
+	codeStoreIndexName   = "codes.index"
+)
+
+// defaultCodeCacheBytes is used when openCodeStore is not given an explicit
+// byte budget, sized to comfortably hold a few thousand average-sized
+// contracts.
+const defaultCodeCacheBytes = 64 * 1024 * 1024
+
+// openCodeStore opens (or creates) a code store in directory. If the
+// directory only contains the legacy codes.json format, it is migrated
+// in-place to the segment/index format before opening.
+func openCodeStore(directory string, cacheBytes int) (*codeStore, error) {
+	if cacheBytes <= 0 {
+		cacheBytes = defaultCodeCacheBytes
+	}
+
+	legacy := filepath.Join(directory, "codes.json")
+	if _, err := os.Stat(legacy); err == nil {
+		if err := migrateLegacyCodeFile(directory, legacy, cacheBytes); err != nil {
+			return nil, fmt.Errorf("failed to migrate legacy code file: %w", err)
+		}
+	}
+
+	return newCodeStoreAt(directory, cacheBytes)
+}
+
+// newCodeStoreAt opens the segment/index pair in directory without
+// checking for a legacy codes.json file, used internally once migration
+// (if any) has already happened.
+func newCodeStoreAt(directory string, cacheBytes int) (*codeStore, error) {
+	segmentPath := filepath.Join(directory, codeStoreSegmentName)
+	segmentFile, err := os.OpenFile(segmentPath, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open code segment file: %w", err)
+	}
+
+	index, err := readCodeIndex(filepath.Join(directory, codeStoreIndexName))
+	if err != nil {
+		return nil, errClosingOnFailure(segmentFile, err)
+	}
+
+	info, err := segmentFile.Stat()
+	if err != nil {
+		return nil, errClosingOnFailure(segmentFile, err)
+	}
+
+	return &codeStore{
+		directory:   directory,
+		segmentFile: segmentFile,
+		segmentSize: info.Size(),
+		index:       index,
+		cache:       newArcCache[common.Hash, []byte](cacheBytes, func(v []byte) int { return len(v) }),
+	}, nil
+}
+
+func errClosingOnFailure(f *os.File, err error) error {
+	_ = f.Close()
+	return err
+}
+
+// Get returns the code for the given hash, reading it from disk on a cache
+// miss. A nil, nil result means the hash is not present in the store.
+func (c *codeStore) Get(hash common.Hash) ([]byte, error) {
+	if code, found := c.cache.Get(hash); found {
+		return code, nil
+	}
+	loc, found := c.index[hash]
+	if !found {
+		return nil, nil
+	}
+	code := make([]byte, loc.length)
+	if _, err := c.segmentFile.ReadAt(code, loc.offset); err != nil {
+		return nil, fmt.Errorf("failed to read code %x from segment file: %w", hash, err)
+	}
+	c.cache.Set(hash, code)
+	return code, nil
+}
+
+// Set appends code to the segment file under hash, unless it is already
+// present. Existing entries for a given hash are never modified, since the
+// store is content-addressed.
+func (c *codeStore) Set(hash common.Hash, code []byte) error {
+	if _, found := c.index[hash]; found {
+		c.cache.Set(hash, code)
+		return nil
+	}
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(code)))
+	if _, err := c.segmentFile.WriteAt(length[:], c.segmentSize); err != nil {
+		return fmt.Errorf("failed to append code length: %w", err)
+	}
+	if _, err := c.segmentFile.WriteAt(code, c.segmentSize+int64(len(length))); err != nil {
+		return fmt.Errorf("failed to append code body: %w", err)
+	}
+	c.index[hash] = codeLocation{offset: c.segmentSize + int64(len(length)), length: uint32(len(code))}
+	c.segmentSize += int64(len(length)) + int64(len(code))
+	c.cache.Set(hash, code)
+	c.dirty = true
+	return nil
+}
+
+// Flush fsyncs the tail of the segment file and rewrites the (small,
+// metadata-only) index file if it has changed since the last Flush.
+func (c *codeStore) Flush() error {
+	if err := c.segmentFile.Sync(); err != nil {
+		return fmt.Errorf("failed to sync code segment file: %w", err)
+	}
+	if !c.dirty {
+		return nil
+	}
+	if err := writeCodeIndex(filepath.Join(c.directory, codeStoreIndexName), c.index); err != nil {
+		return fmt.Errorf("failed to write code index: %w", err)
+	}
+	c.dirty = false
+	return nil
+}
+
+func (c *codeStore) Close() error {
+	if err := c.Flush(); err != nil {
+		return err
+	}
+	return c.segmentFile.Close()
+}
+
+// GetMemoryFootprint reports the ARC cache's real byte usage.
+func (c *codeStore) GetMemoryFootprint() *common.MemoryFootprint {
+	mf := common.NewMemoryFootprint(0)
+	mf.AddChild("cache", common.NewMemoryFootprint(c.cache.GetMemoryFootprint()))
+	return mf
+}
+
+func readCodeIndex(path string) (map[common.Hash]codeLocation, error) {
+	index := map[common.Hash]codeLocation{}
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return index, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := bufio.NewReader(file)
+	var hash common.Hash
+	var entry [12]byte
+	for {
+		if _, err := io.ReadFull(reader, hash[:]); err != nil {
+			if err == io.EOF {
+				return index, nil
+			}
+			return nil, err
+		}
+		if _, err := io.ReadFull(reader, entry[:]); err != nil {
+			return nil, err
+		}
+		index[hash] = codeLocation{
+			offset: int64(binary.BigEndian.Uint64(entry[0:8])),
+			length: binary.BigEndian.Uint32(entry[8:12]),
+		}
+	}
+}
+
+func writeCodeIndex(path string, index map[common.Hash]codeLocation) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() { err = closeAndJoin(file, err) }()
+
+	writer := bufio.NewWriter(file)
+	var entry [12]byte
+	for hash, loc := range index {
+		if _, err := writer.Write(hash[:]); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint64(entry[0:8], uint64(loc.offset))
+		binary.BigEndian.PutUint32(entry[8:12], loc.length)
+		if _, err := writer.Write(entry[:]); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+func closeAndJoin(f *os.File, err error) error {
+	if closeErr := f.Close(); closeErr != nil && err == nil {
+		return closeErr
+	}
+	return err
+}
+
+// migrateLegacyCodeFile converts an existing codes.json (see
+// readCodes/writeCodes) into the segment/index format. The original file
+// is left in place until the migration has succeeded, so a failed
+// migration can be safely retried; on success it is renamed to
+// codes.json.migrated.
+func migrateLegacyCodeFile(directory, legacyPath string, cacheBytes int) error {
+	codes, err := readCodes(legacyPath)
+	if err != nil {
+		return err
+	}
+
+	store, err := newCodeStoreAt(directory, cacheBytes)
+	if err != nil {
+		return err
+	}
+	for hash, code := range codes {
+		if err := store.Set(hash, code); err != nil {
+			return errClosingOnFailure(store.segmentFile, err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		return err
+	}
+	return os.Rename(legacyPath, legacyPath+".migrated")
+}
@@ -10,20 +10,41 @@ import (
 
 const lockFileName = "~lock"
 
-// LockDirectory acquires a lock on the given directory. If needed,
-// the directory is implicitly created. The operation fails if the
+// LockDirectory acquires an exclusive lock on the given directory. If
+// needed, the directory is implicitly created. The operation fails if the
 // lock can not be acquired due to some other thread or process holding
 // the lock or due to an IO error.
 //
 // Note: if successful, the acquired lock needs to be explicitly released.
 // The lock is not automatically released when the process is terminated.
 func LockDirectory(directory string) (common.LockFile, error) {
+	return LockDirectoryMode(directory, common.Exclusive)
+}
+
+// LockDirectoryMode acquires a lock on the given directory under mode. If
+// needed, the directory is implicitly created. A common.Shared lock
+// coexists with other common.Shared locks on the same directory - useful
+// for read-only tools such as dumpers or verifiers attaching to a live
+// directory - but blocks common.Exclusive acquisition, and vice versa.
+//
+// If the only conflicting lock found is stale (see BreakStaleLock), the
+// error wraps common.ErrLockStale rather than common.ErrLockHeld.
+func LockDirectoryMode(directory string, mode common.LockMode) (common.LockFile, error) {
 	if err := os.MkdirAll(directory, 0700); err != nil {
 		return nil, err
 	}
-	lock, err := common.CreateLockFile(filepath.Join(directory, lockFileName))
+	lock, err := common.CreateLockFileMode(filepath.Join(directory, lockFileName), mode, 0)
 	if err != nil {
-		return nil, fmt.Errorf("unable to gain exclusive access to %s: %w", directory, err)
+		return nil, fmt.Errorf("unable to gain access to %s: %w", directory, err)
 	}
 	return lock, nil
+}
+
+// BreakStaleLock forcibly removes a lock left on directory by a crashed
+// process, identified by its heartbeat being older than the default stale
+// TTL. It refuses and returns an error wrapping common.ErrLockHeld if the
+// lock it finds is still live, so a slow-but-alive owner cannot have its
+// lock pulled out from under it.
+func BreakStaleLock(directory string) error {
+	return common.BreakStaleLock(filepath.Join(directory, lockFileName), 0)
 }
\ No newline at end of file
@@ -0,0 +1,52 @@
+package mpt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+func TestLockDirectory_ExclusiveBlocksSecondAcquisition(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := LockDirectory(dir)
+	if err != nil {
+		t.Fatalf("failed to lock directory: %v", err)
+	}
+	defer lock.Release()
+
+	if _, err := LockDirectory(dir); !errors.Is(err, common.ErrLockHeld) {
+		t.Errorf("expected a second exclusive lock attempt to fail, got %v", err)
+	}
+}
+
+func TestLockDirectory_SharedModeAllowsMultipleReaders(t *testing.T) {
+	dir := t.TempDir()
+
+	lock1, err := LockDirectoryMode(dir, common.Shared)
+	if err != nil {
+		t.Fatalf("failed to acquire first shared lock: %v", err)
+	}
+	defer lock1.Release()
+
+	lock2, err := LockDirectoryMode(dir, common.Shared)
+	if err != nil {
+		t.Fatalf("expected a second shared lock to succeed, got %v", err)
+	}
+	defer lock2.Release()
+}
+
+func TestBreakStaleLock_RefusesLiveLock(t *testing.T) {
+	dir := t.TempDir()
+
+	lock, err := LockDirectory(dir)
+	if err != nil {
+		t.Fatalf("failed to lock directory: %v", err)
+	}
+	defer lock.Release()
+
+	if err := BreakStaleLock(dir); !errors.Is(err, common.ErrLockHeld) {
+		t.Errorf("expected BreakStaleLock to refuse a live lock, got %v", err)
+	}
+}
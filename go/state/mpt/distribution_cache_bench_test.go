@@ -0,0 +1,102 @@
+package mpt
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// This file exercises common.GetDistributions' key distributions against
+// newNodeCache (see forest_cache_strategy.go), the cache a Forest's
+// CacheCapacity would eventually configure. ForestConfig/Forest do not
+// exist in this repository snapshot (BLOCKED(forest.go) - see
+// forest_wal.go), so there is no real forest benchmark to drive yet; this
+// exercises the same cache component Forest would use, under capacity
+// pressure, standing in for that until forest.go lands.
+
+// keyHash is the hash function newNodeCache's CacheStrategyPersistent
+// variant needs; the benchmarks below only exercise CacheStrategyLRU
+// (the strategy CacheCapacity governs), so it is unused there but keeps
+// newNodeCache's signature uniform across both strategies.
+func keyHash(k uint32) uint64 { return uint64(k) }
+
+// hitRatioUnderCapacity drives n.GetNext() accesses against an LRU
+// nodeCache capped at capacity entries out of a keyspace of size distinct
+// keys, populating a cache entry on every miss, and reports the fraction
+// of accesses that hit.
+func hitRatioUnderCapacity(dist common.Distribution, size, capacity, accesses int) float64 {
+	cache := newNodeCache[uint32, struct{}](CacheStrategyLRU, capacity, keyHash)
+	hits := 0
+	for i := 0; i < accesses; i++ {
+		key := dist.GetNext() % uint32(size)
+		if _, found := cache.Get(key); found {
+			hits++
+		} else {
+			cache.Set(key, struct{}{})
+		}
+	}
+	return float64(hits) / float64(accesses)
+}
+
+// TestNodeCache_SkewedDistributionsHitMoreThanUniformUnderCapacity confirms
+// that, for a cache capacity far smaller than the keyspace, the skewed
+// distributions GetDistributions offers (Zipfian, Hotspot) - which
+// concentrate repeat accesses onto a small hot set - achieve a
+// substantially higher LRU hit ratio than Uniform access over the same
+// keyspace and capacity. This is the property ForestConfig.CacheCapacity
+// sizing would be tuned against once Forest exists.
+func TestNodeCache_SkewedDistributionsHitMoreThanUniformUnderCapacity(t *testing.T) {
+	const size = 10000
+	const capacity = 100
+	const accesses = 50000
+
+	distributions := common.GetDistributions(size)
+	ratios := make(map[string]float64, len(distributions))
+	for _, dist := range distributions {
+		ratios[dist.Label] = hitRatioUnderCapacity(dist, size, capacity, accesses)
+	}
+
+	uniform, ok := ratios["Uniform"]
+	if !ok {
+		t.Fatalf("expected a Uniform distribution from GetDistributions")
+	}
+	for _, label := range []string{"Zipfian", "Hotspot"} {
+		skewed, ok := ratios[label]
+		if !ok {
+			t.Fatalf("expected a %s distribution from GetDistributions", label)
+		}
+		if skewed <= uniform {
+			t.Errorf("expected %s hit ratio (%.4f) to beat Uniform (%.4f) under a capacity-%d cache over a %d-key space", label, skewed, uniform, capacity, size)
+		}
+	}
+}
+
+// BenchmarkNodeCache_Distributions measures LRU nodeCache hit ratio under
+// every distribution GetDistributions offers, at a fixed capacity much
+// smaller than the keyspace - the shape of measurement
+// ForestConfig.CacheCapacity tuning would use, run here against the cache
+// component itself rather than a full Forest.
+func BenchmarkNodeCache_Distributions(b *testing.B) {
+	const size = 100000
+	const capacity = 1000
+
+	for _, dist := range common.GetDistributions(size) {
+		dist := dist
+		b.Run(dist.Label, func(b *testing.B) {
+			cache := newNodeCache[uint32, struct{}](CacheStrategyLRU, capacity, keyHash)
+			hits := 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				key := dist.GetNext() % uint32(size)
+				if _, found := cache.Get(key); found {
+					hits++
+				} else {
+					cache.Set(key, struct{}{})
+				}
+			}
+			if b.N > 0 {
+				b.ReportMetric(float64(hits)/float64(b.N), "hit-ratio")
+			}
+		})
+	}
+}
@@ -0,0 +1,402 @@
+package mpt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// DumpFormat selects the on-wire encoding used by Dump/Import.
+type DumpFormat int
+
+const (
+	// DumpFormatBinary is a compact length-prefixed binary framing suitable
+	// for snapshot transport between nodes.
+	DumpFormatBinary DumpFormat = iota
+	// DumpFormatJSON is a newline-delimited JSON encoding intended for
+	// tooling and manual inspection.
+	DumpFormatJSON
+)
+
+// DumpOptions configures what Dump writes and in which encoding.
+type DumpOptions struct {
+	Format         DumpFormat
+	IncludeStorage bool
+	IncludeCode    bool
+}
+
+// dumpRecord is the logical unit written by Dump and read back by Import,
+// shared by both the binary and the JSON encodings.
+type dumpRecord struct {
+	Address  common.Address `json:"address"`
+	Balance  common.Balance `json:"balance"`
+	Nonce    common.Nonce   `json:"nonce"`
+	CodeHash common.Hash    `json:"codeHash"`
+	Storage  []storageSlot  `json:"storage,omitempty"`
+	Code     []byte         `json:"code,omitempty"`
+}
+
+// storageSlot is a single key/value pair of an account's storage, encoded
+// as a pair rather than a map so the record round-trips through JSON (whose
+// object keys must be strings).
+type storageSlot struct {
+	Key   common.Key   `json:"key"`
+	Value common.Value `json:"value"`
+}
+
+// Dump streams every account known to s - in hashed-key order, so two dumps
+// of equivalent states are byte-identical - into w using the encoding
+// selected by opts. Depending on opts, per-account storage and bytecode are
+// included inline.
+//
+// Dump is built on NodeIterator (see node_iterator.go) and inherits its
+// limitation: if s was opened from an existing, non-empty on-disk trie
+// rather than built up from genesis through this MptState instance, Dump
+// fails with ErrNodeIteratorNeedsForestWalk rather than silently streaming
+// an incomplete (or empty) dump.
+func (s *MptState) Dump(w io.Writer, opts DumpOptions) (err error) {
+	it, err := s.NodeIterator(nil)
+	if err != nil {
+		return fmt.Errorf("failed to start dump: %w", err)
+	}
+
+	buffered := bufio.NewWriter(w)
+	defer func() {
+		err = deferredFlush(buffered, err)
+	}()
+
+	enc := newDumpEncoder(buffered, opts.Format)
+	for it.Next() {
+		address := it.LeafKey() // hashed address; raw address recovered via the trie below.
+		record, err := s.buildDumpRecord(address, opts)
+		if err != nil {
+			return err
+		}
+		if err := enc.encode(record); err != nil {
+			return fmt.Errorf("failed to write dump record: %w", err)
+		}
+	}
+	if err := it.Error(); err != nil {
+		return fmt.Errorf("dump iteration failed: %w", err)
+	}
+	return nil
+}
+
+// buildDumpRecord resolves the content of a single dumped account. Since
+// NodeIterator only exposes hashed keys, the raw address is looked up
+// through the address index maintained alongside the trie.
+func (s *MptState) buildDumpRecord(hashedAddress common.Hash, opts DumpOptions) (dumpRecord, error) {
+	pos := sortFindHashedEntry(s.addresses.entries, hashedAddress)
+	if pos < 0 {
+		return dumpRecord{}, fmt.Errorf("dangling hashed address %x in index", hashedAddress)
+	}
+	address := s.addresses.entries[pos].address
+
+	info, _, err := s.trie.GetAccountInfo(address)
+	if err != nil {
+		return dumpRecord{}, fmt.Errorf("failed to read account %x: %w", address, err)
+	}
+
+	record := dumpRecord{Address: address, Balance: info.Balance, Nonce: info.Nonce, CodeHash: info.CodeHash}
+	if opts.IncludeCode {
+		code, err := s.codes.Get(info.CodeHash)
+		if err != nil {
+			return dumpRecord{}, fmt.Errorf("failed to read code %x: %w", info.CodeHash, err)
+		}
+		record.Code = code
+	}
+	if opts.IncludeStorage {
+		var storage []storageSlot
+		for _, hashedKey := range s.addresses.storageFrom(address, nil) {
+			key, found := s.addresses.rawKeys[hashedKey]
+			if !found {
+				continue
+			}
+			value, err := s.trie.GetValue(address, key)
+			if err != nil {
+				return dumpRecord{}, fmt.Errorf("failed to read storage %x/%x: %w", address, key, err)
+			}
+			storage = append(storage, storageSlot{Key: key, Value: value})
+		}
+		record.Storage = storage
+	}
+	return record, nil
+}
+
+func sortFindHashedEntry(entries []addressEntry, hash common.Hash) int {
+	for i, entry := range entries {
+		if entry.hashedKey == hash {
+			return i
+		}
+	}
+	return -1
+}
+
+// Import reads a dump produced by Dump and applies it to s, which must
+// either be empty or already contain a consistent prefix of the same dump
+// (Import is resumable: it records the hashed key of the last consumed
+// record in a sidecar file inside the state directory and skips
+// already-imported records on a subsequent call). After all records are
+// applied, the resulting root is compared against expectedRoot.
+func (s *MptState) Import(r io.Reader, expectedRoot common.Hash) (err error) {
+	sidecar := filepath.Join(s.codes.directory, "import-cursor")
+	resumeAfter, _ := readImportCursor(sidecar)
+
+	dec := newDumpDecoder(r)
+	skipping := resumeAfter != (common.Hash{})
+	for {
+		record, ok, err := dec.decode()
+		if err != nil {
+			return fmt.Errorf("failed to decode dump record: %w", err)
+		}
+		if !ok {
+			break
+		}
+
+		hashedAddress := keccak(record.Address[:])
+		if skipping {
+			if hashedAddress == resumeAfter {
+				skipping = false
+			}
+			continue
+		}
+
+		if err := s.applyDumpRecord(record); err != nil {
+			return err
+		}
+		if err := writeImportCursor(sidecar, hashedAddress); err != nil {
+			return fmt.Errorf("failed to persist import cursor: %w", err)
+		}
+	}
+
+	root, err := s.GetHash()
+	if err != nil {
+		return fmt.Errorf("failed to compute root after import: %w", err)
+	}
+	if root != expectedRoot {
+		return fmt.Errorf("imported state root %x does not match expected root %x", root, expectedRoot)
+	}
+	return os.Remove(sidecar)
+}
+
+func (s *MptState) applyDumpRecord(record dumpRecord) error {
+	if err := s.CreateAccount(record.Address); err != nil {
+		return fmt.Errorf("failed to import account %x: %w", record.Address, err)
+	}
+	if err := s.SetBalance(record.Address, record.Balance); err != nil {
+		return err
+	}
+	if err := s.SetNonce(record.Address, record.Nonce); err != nil {
+		return err
+	}
+	if record.Code != nil {
+		if err := s.SetCode(record.Address, record.Code); err != nil {
+			return err
+		}
+	}
+	for _, slot := range record.Storage {
+		if err := s.SetStorage(record.Address, slot.Key, slot.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readImportCursor(path string) (common.Hash, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return common.Hash{}, nil
+	}
+	var h common.Hash
+	if len(data) != len(h) {
+		return common.Hash{}, fmt.Errorf("corrupted import cursor")
+	}
+	copy(h[:], data)
+	return h, nil
+}
+
+func writeImportCursor(path string, h common.Hash) error {
+	return os.WriteFile(path, h[:], 0600)
+}
+
+func deferredFlush(w *bufio.Writer, err error) error {
+	if flushErr := w.Flush(); flushErr != nil && err == nil {
+		return flushErr
+	}
+	return err
+}
+
+// dumpEncoder/dumpDecoder abstract over DumpFormatBinary/DumpFormatJSON so
+// that Dump/Import do not need to branch on the format at every call site.
+
+type dumpEncoder interface {
+	encode(record dumpRecord) error
+}
+
+func newDumpEncoder(w io.Writer, format DumpFormat) dumpEncoder {
+	if format == DumpFormatJSON {
+		return &jsonDumpEncoder{enc: json.NewEncoder(w)}
+	}
+	return &binaryDumpEncoder{w: w}
+}
+
+type jsonDumpEncoder struct{ enc *json.Encoder }
+
+func (e *jsonDumpEncoder) encode(record dumpRecord) error { return e.enc.Encode(record) }
+
+// binaryDumpEncoder writes each dumpRecord in a fixed-width-plus-varint
+// framing instead of carrying JSON's field names, punctuation and per-byte
+// base64 blow-up over the wire:
+//
+//	address(20) balance(32) nonce(8) codeHash(32)
+//	hasCode(1) [codeLen(uvarint) code(codeLen)]
+//	storageCount(uvarint) [key(32) value(32)]*storageCount
+func (e *binaryDumpEncoder) encode(record dumpRecord) error {
+	var head [20 + 32 + 8 + 32]byte
+	pos := 0
+	copy(head[pos:], record.Address[:])
+	pos += 20
+	copy(head[pos:], record.Balance[:])
+	pos += 32
+	binary.BigEndian.PutUint64(head[pos:pos+8], record.Nonce.ToUint64())
+	pos += 8
+	copy(head[pos:], record.CodeHash[:])
+	if _, err := e.w.Write(head[:]); err != nil {
+		return err
+	}
+
+	if err := e.writeCode(record.Code); err != nil {
+		return err
+	}
+	return e.writeStorage(record.Storage)
+}
+
+type binaryDumpEncoder struct {
+	w    io.Writer
+	uvar [binary.MaxVarintLen64]byte
+}
+
+func (e *binaryDumpEncoder) writeCode(code []byte) error {
+	if code == nil {
+		_, err := e.w.Write([]byte{0})
+		return err
+	}
+	if _, err := e.w.Write([]byte{1}); err != nil {
+		return err
+	}
+	n := binary.PutUvarint(e.uvar[:], uint64(len(code)))
+	if _, err := e.w.Write(e.uvar[:n]); err != nil {
+		return err
+	}
+	_, err := e.w.Write(code)
+	return err
+}
+
+func (e *binaryDumpEncoder) writeStorage(storage []storageSlot) error {
+	n := binary.PutUvarint(e.uvar[:], uint64(len(storage)))
+	if _, err := e.w.Write(e.uvar[:n]); err != nil {
+		return err
+	}
+	for _, slot := range storage {
+		if _, err := e.w.Write(slot.Key[:]); err != nil {
+			return err
+		}
+		if _, err := e.w.Write(slot.Value[:]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type dumpDecoder struct {
+	r       *bufio.Reader
+	jsonDec *json.Decoder
+	isJSON  bool
+}
+
+func newDumpDecoder(r io.Reader) *dumpDecoder {
+	buffered := bufio.NewReader(r)
+	// A JSON dump starts with the first record's "address" field; a single
+	// '{' byte could just as well be the first byte of a binary address.
+	first, err := buffered.Peek(len(`{"address":`))
+	isJSON := err == nil && string(first) == `{"address":`
+	d := &dumpDecoder{r: buffered, isJSON: isJSON}
+	if isJSON {
+		d.jsonDec = json.NewDecoder(buffered)
+	}
+	return d
+}
+
+func (d *dumpDecoder) decode() (dumpRecord, bool, error) {
+	if d.isJSON {
+		var record dumpRecord
+		if err := d.jsonDec.Decode(&record); err != nil {
+			if err == io.EOF {
+				return dumpRecord{}, false, nil
+			}
+			return dumpRecord{}, false, err
+		}
+		return record, true, nil
+	}
+	return d.decodeBinary()
+}
+
+func (d *dumpDecoder) decodeBinary() (dumpRecord, bool, error) {
+	var head [20 + 32 + 8 + 32]byte
+	if _, err := io.ReadFull(d.r, head[:]); err != nil {
+		if err == io.EOF {
+			return dumpRecord{}, false, nil
+		}
+		return dumpRecord{}, false, err
+	}
+
+	var record dumpRecord
+	pos := 0
+	copy(record.Address[:], head[pos:pos+20])
+	pos += 20
+	copy(record.Balance[:], head[pos:pos+32])
+	pos += 32
+	record.Nonce = common.ToNonce(binary.BigEndian.Uint64(head[pos : pos+8]))
+	pos += 8
+	copy(record.CodeHash[:], head[pos:pos+32])
+
+	hasCode, err := d.r.ReadByte()
+	if err != nil {
+		return dumpRecord{}, false, fmt.Errorf("truncated dump record: %w", err)
+	}
+	if hasCode != 0 {
+		codeLen, err := binary.ReadUvarint(d.r)
+		if err != nil {
+			return dumpRecord{}, false, fmt.Errorf("truncated dump record code length: %w", err)
+		}
+		code := make([]byte, codeLen)
+		if _, err := io.ReadFull(d.r, code); err != nil {
+			return dumpRecord{}, false, fmt.Errorf("truncated dump record code: %w", err)
+		}
+		record.Code = code
+	}
+
+	storageCount, err := binary.ReadUvarint(d.r)
+	if err != nil {
+		return dumpRecord{}, false, fmt.Errorf("truncated dump record storage count: %w", err)
+	}
+	if storageCount > 0 {
+		storage := make([]storageSlot, storageCount)
+		for i := range storage {
+			if _, err := io.ReadFull(d.r, storage[i].Key[:]); err != nil {
+				return dumpRecord{}, false, fmt.Errorf("truncated dump record storage key: %w", err)
+			}
+			if _, err := io.ReadFull(d.r, storage[i].Value[:]); err != nil {
+				return dumpRecord{}, false, fmt.Errorf("truncated dump record storage value: %w", err)
+			}
+		}
+		record.Storage = storage
+	}
+	return record, true, nil
+}
@@ -0,0 +1,129 @@
+package mpt
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+func TestBinaryDumpEncoder_RoundTripsWithoutStorageOrCode(t *testing.T) {
+	record := dumpRecord{
+		Address:  common.Address{1, 2, 3},
+		Balance:  common.Balance{4, 5, 6},
+		Nonce:    common.ToNonce(42),
+		CodeHash: common.Hash{7, 8, 9},
+	}
+
+	var buf bytes.Buffer
+	enc := &binaryDumpEncoder{w: &buf}
+	if err := enc.encode(record); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	dec := newDumpDecoder(&buf)
+	got, ok, err := dec.decode()
+	if err != nil || !ok {
+		t.Fatalf("decode failed: ok=%v err=%v", ok, err)
+	}
+	if got.Address != record.Address || got.Balance != record.Balance || got.Nonce != record.Nonce || got.CodeHash != record.CodeHash {
+		t.Errorf("decoded record = %+v, want %+v", got, record)
+	}
+	if got.Code != nil || got.Storage != nil {
+		t.Errorf("expected no code/storage, got code=%v storage=%v", got.Code, got.Storage)
+	}
+}
+
+func TestBinaryDumpEncoder_RoundTripsWithCodeAndStorage(t *testing.T) {
+	record := dumpRecord{
+		Address:  common.Address{1},
+		Balance:  common.Balance{2},
+		Nonce:    common.ToNonce(7),
+		CodeHash: common.Hash{3},
+		Code:     []byte{0xDE, 0xAD, 0xBE, 0xEF},
+		Storage: []storageSlot{
+			{Key: common.Key{1}, Value: common.Value{1}},
+			{Key: common.Key{2}, Value: common.Value{2}},
+		},
+	}
+
+	var buf bytes.Buffer
+	enc := &binaryDumpEncoder{w: &buf}
+	if err := enc.encode(record); err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	dec := newDumpDecoder(&buf)
+	got, ok, err := dec.decode()
+	if err != nil || !ok {
+		t.Fatalf("decode failed: ok=%v err=%v", ok, err)
+	}
+	if !bytes.Equal(got.Code, record.Code) {
+		t.Errorf("decoded code = %x, want %x", got.Code, record.Code)
+	}
+	if len(got.Storage) != len(record.Storage) {
+		t.Fatalf("decoded storage length = %d, want %d", len(got.Storage), len(record.Storage))
+	}
+	for i := range record.Storage {
+		if got.Storage[i] != record.Storage[i] {
+			t.Errorf("decoded storage[%d] = %+v, want %+v", i, got.Storage[i], record.Storage[i])
+		}
+	}
+}
+
+func TestBinaryDumpEncoder_RoundTripsMultipleRecords(t *testing.T) {
+	records := []dumpRecord{
+		{Address: common.Address{1}, Nonce: common.ToNonce(1)},
+		{Address: common.Address{2}, Nonce: common.ToNonce(2), Code: []byte{0x01}},
+		{Address: common.Address{3}, Nonce: common.ToNonce(3), Storage: []storageSlot{{Key: common.Key{9}, Value: common.Value{9}}}},
+	}
+
+	var buf bytes.Buffer
+	enc := &binaryDumpEncoder{w: &buf}
+	for _, r := range records {
+		if err := enc.encode(r); err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+	}
+
+	dec := newDumpDecoder(&buf)
+	for i, want := range records {
+		got, ok, err := dec.decode()
+		if err != nil || !ok {
+			t.Fatalf("decode record %d failed: ok=%v err=%v", i, ok, err)
+		}
+		if got.Address != want.Address || got.Nonce != want.Nonce {
+			t.Errorf("record %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if _, ok, err := dec.decode(); ok || err != nil {
+		t.Errorf("expected clean EOF after last record, got ok=%v err=%v", ok, err)
+	}
+}
+
+// TestBinaryDumpEncoder_IsMoreCompactThanJSON pins the compactness claim the
+// binary format is supposed to deliver: no field names, no base64, no
+// per-record punctuation.
+func TestBinaryDumpEncoder_IsMoreCompactThanJSON(t *testing.T) {
+	record := dumpRecord{
+		Address:  common.Address{1, 2, 3, 4},
+		Balance:  common.Balance{5, 6, 7, 8},
+		Nonce:    common.ToNonce(123456),
+		CodeHash: common.Hash{9, 9, 9},
+	}
+
+	var binBuf bytes.Buffer
+	if err := (&binaryDumpEncoder{w: &binBuf}).encode(record); err != nil {
+		t.Fatalf("binary encode failed: %v", err)
+	}
+
+	var jsonBuf bytes.Buffer
+	if err := (&jsonDumpEncoder{enc: json.NewEncoder(&jsonBuf)}).encode(record); err != nil {
+		t.Fatalf("json encode failed: %v", err)
+	}
+
+	if binBuf.Len() >= jsonBuf.Len() {
+		t.Errorf("binary encoding (%d bytes) is not more compact than JSON (%d bytes)", binBuf.Len(), jsonBuf.Len())
+	}
+}
@@ -0,0 +1,90 @@
+package mpt
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// forestReader is the read side of the minimal Forest surface visible in
+// this snapshot (see forestMutator in forest_wal.go for the write side).
+type forestReader interface {
+	GetAccountInfo(root *NodeReference, address common.Address) (AccountInfo, bool, error)
+}
+
+// forestWriter is the single-call write side of the same minimal surface,
+// shared with forestMutator in forest_wal.go (forestMutator additionally
+// requires SetValue, which SetAccountInfoBatch has no use for).
+type forestWriter interface {
+	SetAccountInfo(root *NodeReference, address common.Address, info AccountInfo) (NodeReference, error)
+}
+
+// GetAccountInfoBatch resolves every address in addresses against the same
+// root concurrently, bounded to maxConcurrency goroutines at a time via
+// common.WorkerPool (maxConcurrency <= 0 selects GOMAXPROCS), and returns
+// the results in the same order as addresses. Reads against a fixed root
+// are independent of one another, so this is safe to parallelize purely in
+// terms of the visible Forest API - unlike a SetAccountInfoBatch, which
+// would need one: Forest.SetAccountInfo takes the root produced by the
+// previous call and returns a new one (see forest_test.go), so applying a
+// batch of writes is inherently a sequential chain under the functional
+// NodeReference-threading model visible here, and actually parallelizing
+// independent branch subtrees (as this request also asks of
+// updateHashesFor, exposed as ForestConfig.MaxConcurrency) requires walking
+// Forest's own node tree, which is not part of this repository snapshot.
+func GetAccountInfoBatch(reader forestReader, root *NodeReference, addresses []common.Address, maxConcurrency int) ([]AccountInfo, []bool, error) {
+	infos := make([]AccountInfo, len(addresses))
+	exists := make([]bool, len(addresses))
+
+	pool := common.NewWorkerPool(maxConcurrency)
+	for i, address := range addresses {
+		i, address := i, address
+		pool.Submit(func() error {
+			info, found, err := reader.GetAccountInfo(root, address)
+			if err != nil {
+				return fmt.Errorf("failed to read account %x: %w", address, err)
+			}
+			infos[i] = info
+			exists[i] = found
+			return nil
+		})
+	}
+	if err := pool.Wait(); err != nil {
+		return nil, nil, err
+	}
+	return infos, exists, nil
+}
+
+// SetAccountInfoBatch applies every (addresses[i], infos[i]) pair against
+// root in order, threading the root produced by each write into the next,
+// and returns the final root.
+//
+// This cannot be parallelized the way GetAccountInfoBatch is: each write
+// depends on the root the previous one produced (forestWriter.SetAccountInfo
+// takes a root and returns the next one), so applying the batch is inherently a sequential chain under
+// this package's functional NodeReference-threading model - that part is
+// fundamental to the API shape visible here, not a gap in this
+// implementation. What the originating request also asked for -
+// parallelizing the underlying hash recomputation across independent
+// branch subtrees via ForestConfig.MaxConcurrency, so a sequential batch of
+// writes is still cheaper to commit than N independent ones - requires
+// walking Forest's own node tree to identify which subtrees are
+// independent. That tree is not part of this repository snapshot
+// (BLOCKED(forest.go): see forestMutator's doc comment in forest_wal.go for
+// the same gap), so SetAccountInfoBatch applies the writes correctly and
+// in order, without the subtree-parallel hashing the request asked for.
+func SetAccountInfoBatch(writer forestWriter, root *NodeReference, addresses []common.Address, infos []AccountInfo) (NodeReference, error) {
+	if len(addresses) != len(infos) {
+		return *root, fmt.Errorf("addresses and infos must have the same length, got %d and %d", len(addresses), len(infos))
+	}
+
+	current := *root
+	for i, address := range addresses {
+		next, err := writer.SetAccountInfo(&current, address, infos[i])
+		if err != nil {
+			return current, fmt.Errorf("failed to set account %d (%x): %w", i, address, err)
+		}
+		current = next
+	}
+	return current, nil
+}
@@ -0,0 +1,119 @@
+package mpt
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+type fakeForestReader struct {
+	infos map[common.Address]AccountInfo
+}
+
+func (f *fakeForestReader) GetAccountInfo(root *NodeReference, address common.Address) (AccountInfo, bool, error) {
+	info, found := f.infos[address]
+	return info, found, nil
+}
+
+func TestGetAccountInfoBatch_ResolvesEveryAddressInOrder(t *testing.T) {
+	reader := &fakeForestReader{infos: map[common.Address]AccountInfo{}}
+	addresses := make([]common.Address, 20)
+	for i := range addresses {
+		addresses[i] = common.Address{byte(i)}
+		reader.infos[addresses[i]] = AccountInfo{Nonce: common.ToNonce(uint64(i + 1))}
+	}
+
+	root := NewNodeReference(EmptyId())
+	infos, exists, err := GetAccountInfoBatch(reader, &root, addresses, 4)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, address := range addresses {
+		if !exists[i] {
+			t.Errorf("expected account %x to exist", address)
+		}
+		if infos[i].Nonce != common.ToNonce(uint64(i+1)) {
+			t.Errorf("account %x: expected nonce %d, got %v", address, i+1, infos[i].Nonce)
+		}
+	}
+}
+
+func TestGetAccountInfoBatch_MissingAccountReportsNotFound(t *testing.T) {
+	reader := &fakeForestReader{infos: map[common.Address]AccountInfo{}}
+	root := NewNodeReference(EmptyId())
+
+	_, exists, err := GetAccountInfoBatch(reader, &root, []common.Address{{1}}, 2)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exists[0] {
+		t.Errorf("expected account to be reported missing")
+	}
+}
+
+// fakeForestWriter is a minimal in-memory stand-in for Forest's write side,
+// sufficient to exercise SetAccountInfoBatch's sequencing and error
+// propagation without depending on Forest's own (absent from this
+// snapshot) implementation. Every write returns the same empty root; the
+// tests check the order of writes through applied, not through the roots.
+type fakeForestWriter struct {
+	infos     map[common.Address]AccountInfo
+	applied   []common.Address
+	failAfter int // -1 disables; otherwise fail on the call with this index
+}
+
+func (f *fakeForestWriter) SetAccountInfo(root *NodeReference, address common.Address, info AccountInfo) (NodeReference, error) {
+	if f.failAfter >= 0 && len(f.applied) == f.failAfter {
+		return *root, fmt.Errorf("simulated failure writing %x", address)
+	}
+	f.infos[address] = info
+	f.applied = append(f.applied, address)
+	return NewNodeReference(EmptyId()), nil
+}
+
+func TestSetAccountInfoBatch_AppliesEveryWriteInOrder(t *testing.T) {
+	writer := &fakeForestWriter{infos: map[common.Address]AccountInfo{}, failAfter: -1}
+	addresses := make([]common.Address, 10)
+	infos := make([]AccountInfo, 10)
+	for i := range addresses {
+		addresses[i] = common.Address{byte(i)}
+		infos[i] = AccountInfo{Nonce: common.ToNonce(uint64(i + 1))}
+	}
+
+	root := NewNodeReference(EmptyId())
+	if _, err := SetAccountInfoBatch(writer, &root, addresses, infos); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for i, address := range addresses {
+		if writer.applied[i] != address {
+			t.Errorf("expected write %d to be for %x, got %x", i, address, writer.applied[i])
+		}
+		if got := writer.infos[address]; got != infos[i] {
+			t.Errorf("account %x: expected %v, got %v", address, infos[i], got)
+		}
+	}
+}
+
+func TestSetAccountInfoBatch_StopsAndReportsErrorPartway(t *testing.T) {
+	writer := &fakeForestWriter{infos: map[common.Address]AccountInfo{}, failAfter: 2}
+	addresses := []common.Address{{1}, {2}, {3}, {4}}
+	infos := make([]AccountInfo, len(addresses))
+
+	root := NewNodeReference(EmptyId())
+	if _, err := SetAccountInfoBatch(writer, &root, addresses, infos); err == nil {
+		t.Fatalf("expected an error from the simulated failure")
+	}
+	if len(writer.applied) != 2 {
+		t.Errorf("expected exactly 2 writes to have been applied before the failure, got %d", len(writer.applied))
+	}
+}
+
+func TestSetAccountInfoBatch_RejectsMismatchedLengths(t *testing.T) {
+	writer := &fakeForestWriter{infos: map[common.Address]AccountInfo{}, failAfter: -1}
+	root := NewNodeReference(EmptyId())
+
+	if _, err := SetAccountInfoBatch(writer, &root, []common.Address{{1}}, nil); err == nil {
+		t.Errorf("expected an error for mismatched addresses/infos lengths")
+	}
+}
@@ -0,0 +1,105 @@
+package mpt
+
+import "github.com/Fantom-foundation/Carmen/go/common"
+
+// CacheStrategy selects the cache backend a Forest uses to avoid
+// repeatedly deserializing nodes already read from a file stock. It is
+// meant to become a field on ForestConfig; ForestConfig and Forest are not
+// part of this repository snapshot (only forest_test.go is - BLOCKED(forest.go)),
+// so CacheStrategy cannot be plumbed all the way through Forest.getReadAccess
+// and Freeze yet. What it does select is newNodeCache below, a self-contained
+// cache abstraction built against the two strategies' real backing stores
+// (common.Cache and common.PersistentMap), so the remaining integration step
+// is instantiating one of these with the right strategy inside Forest, not
+// inventing the cache implementations themselves.
+//
+//   - CacheStrategyLRU is the current common.NewCache(-based) eviction
+//     strategy: a fixed-capacity cache shared by every root, appropriate
+//     for Mutable forests where old roots are not expected to be revisited.
+//   - CacheStrategyPersistent keys node lookups by common.PersistentMap
+//     (see persistent_map.go in the common package) instead: each frozen
+//     root (Forest.Freeze) would hold its own reference to the persistent
+//     map snapshot produced by the writes leading up to it, making repeated
+//     reads against an old, frozen root (as archive workloads do, e.g.
+//     TestForest_InArchiveModeHistoryIsPreserved) an O(1) hit against that
+//     root's own snapshot instead of contending over one mutable LRU that
+//     evicts entries belonging to roots other than the one most recently
+//     touched.
+//
+// Wiring this into Forest.getReadAccess (to look up the right snapshot for
+// the access's root) and into Freeze (to fork a new persistent-map
+// snapshot from the one the pre-freeze root was using) requires Forest's
+// node representation, which is not part of this snapshot.
+type CacheStrategy int
+
+const (
+	CacheStrategyLRU CacheStrategy = iota
+	CacheStrategyPersistent
+)
+
+// nodeCache is the minimal cache surface both CacheStrategy backends
+// implement, so a future Forest.getReadAccess/Freeze can use either one
+// through a single interface without caring which is configured.
+//
+// Snapshot freezes the cache's current contents into an independent
+// nodeCache that is unaffected by Set calls made on the receiver
+// afterwards - the operation Forest.Freeze needs to give a newly-frozen
+// root its own stable view. For lruNodeCache this is a correctness
+// tradeoff the request's comment already calls out: archive reads against
+// an old root can still be evicted by a mutable LRU shared with every
+// other root, so lruNodeCache's Snapshot is a plain alias onto the same
+// underlying cache rather than a real fork (matching CacheStrategyLRU's
+// documented behavior above). persistentNodeCache's Snapshot is a true
+// O(1) fork: the two caches share every node unchanged by subsequent
+// writes and diverge only where they're set again.
+type nodeCache[K comparable, V any] interface {
+	Get(key K) (V, bool)
+	Set(key K, value V)
+	Snapshot() nodeCache[K, V]
+}
+
+// newNodeCache creates a nodeCache backed by the cache strategy, sized to
+// capacity distinct entries for CacheStrategyLRU (CacheStrategyPersistent
+// ignores capacity: a PersistentMap grows to fit its content and relies on
+// forked-away snapshots becoming collectible instead of capacity-based
+// eviction). hash must be provided for CacheStrategyPersistent; it is
+// unused otherwise.
+func newNodeCache[K comparable, V any](strategy CacheStrategy, capacity int, hash func(K) uint64) nodeCache[K, V] {
+	switch strategy {
+	case CacheStrategyPersistent:
+		return &persistentNodeCache[K, V]{tree: common.NewPersistentMap[K, V](hash)}
+	default:
+		return &lruNodeCache[K, V]{cache: common.NewCache[K, V](capacity)}
+	}
+}
+
+// lruNodeCache implements nodeCache over a plain common.Cache, the
+// CacheStrategyLRU backend.
+type lruNodeCache[K comparable, V any] struct {
+	cache *common.Cache[K, V]
+}
+
+func (c *lruNodeCache[K, V]) Get(key K) (V, bool) { return c.cache.Get(key) }
+func (c *lruNodeCache[K, V]) Set(key K, value V)  { c.cache.Set(key, value) }
+
+func (c *lruNodeCache[K, V]) Snapshot() nodeCache[K, V] {
+	return c
+}
+
+// persistentNodeCache implements nodeCache over a common.PersistentMap,
+// the CacheStrategyPersistent backend. Each Set forks a new map generation
+// (see PersistentMap.Set), so a Snapshot taken before a Set is unaffected
+// by it.
+type persistentNodeCache[K comparable, V any] struct {
+	tree *common.PersistentMap[K, V]
+}
+
+func (c *persistentNodeCache[K, V]) Get(key K) (V, bool) { return c.tree.Get(key) }
+
+func (c *persistentNodeCache[K, V]) Set(key K, value V) {
+	c.tree = c.tree.Set(key, value)
+}
+
+func (c *persistentNodeCache[K, V]) Snapshot() nodeCache[K, V] {
+	return &persistentNodeCache[K, V]{tree: c.tree}
+}
@@ -0,0 +1,77 @@
+package mpt
+
+import "testing"
+
+func uint64Hash(k uint64) uint64 { return k }
+
+func TestNodeCache_LRUStoresAndRetrieves(t *testing.T) {
+	cache := newNodeCache[uint64, string](CacheStrategyLRU, 16, uint64Hash)
+
+	cache.Set(1, "one")
+	cache.Set(2, "two")
+
+	if got, found := cache.Get(1); !found || got != "one" {
+		t.Errorf("expected (one, true), got (%v, %v)", got, found)
+	}
+	if _, found := cache.Get(3); found {
+		t.Errorf("expected key 3 to be absent")
+	}
+}
+
+func TestNodeCache_PersistentStoresAndRetrieves(t *testing.T) {
+	cache := newNodeCache[uint64, string](CacheStrategyPersistent, 16, uint64Hash)
+
+	cache.Set(1, "one")
+	cache.Set(2, "two")
+
+	if got, found := cache.Get(1); !found || got != "one" {
+		t.Errorf("expected (one, true), got (%v, %v)", got, found)
+	}
+	if _, found := cache.Get(3); found {
+		t.Errorf("expected key 3 to be absent")
+	}
+}
+
+func TestNodeCache_PersistentSnapshotIsUnaffectedByLaterWrites(t *testing.T) {
+	cache := newNodeCache[uint64, string](CacheStrategyPersistent, 16, uint64Hash)
+	cache.Set(1, "one")
+
+	frozen := cache.Snapshot()
+
+	// Mutate the live cache after taking the snapshot.
+	cache.Set(1, "one-updated")
+	cache.Set(2, "two")
+
+	if got, found := frozen.Get(1); !found || got != "one" {
+		t.Errorf("snapshot should keep seeing the pre-snapshot value, got (%v, %v)", got, found)
+	}
+	if _, found := frozen.Get(2); found {
+		t.Errorf("snapshot should not see writes made after it was taken")
+	}
+
+	// The live cache should see both updates.
+	if got, found := cache.Get(1); !found || got != "one-updated" {
+		t.Errorf("live cache should see the update, got (%v, %v)", got, found)
+	}
+	if got, found := cache.Get(2); !found || got != "two" {
+		t.Errorf("live cache should see the new entry, got (%v, %v)", got, found)
+	}
+}
+
+func TestNodeCache_LRUCapacityEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newNodeCache[uint64, string](CacheStrategyLRU, 2, uint64Hash)
+
+	cache.Set(1, "one")
+	cache.Set(2, "two")
+	cache.Set(3, "three") // should evict key 1, the least recently used
+
+	if _, found := cache.Get(1); found {
+		t.Errorf("expected key 1 to have been evicted")
+	}
+	if _, found := cache.Get(2); !found {
+		t.Errorf("expected key 2 to still be cached")
+	}
+	if _, found := cache.Get(3); !found {
+		t.Errorf("expected key 3 to be cached")
+	}
+}
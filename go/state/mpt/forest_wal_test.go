@@ -0,0 +1,137 @@
+package mpt
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// fakeForestMutator is a minimal in-memory stand-in for Forest, sufficient
+// to exercise forestWAL/ReplayForestWAL without depending on Forest's own
+// (absent from this snapshot) implementation.
+type fakeForestMutator struct {
+	infos  map[common.Address]AccountInfo
+	values map[common.Address]map[common.Key]common.Value
+}
+
+func newFakeForestMutator() *fakeForestMutator {
+	return &fakeForestMutator{
+		infos:  map[common.Address]AccountInfo{},
+		values: map[common.Address]map[common.Key]common.Value{},
+	}
+}
+
+func (f *fakeForestMutator) SetAccountInfo(root *NodeReference, address common.Address, info AccountInfo) (NodeReference, error) {
+	f.infos[address] = info
+	return NewNodeReference(EmptyId()), nil
+}
+
+func (f *fakeForestMutator) SetValue(root *NodeReference, address common.Address, key common.Key, value common.Value) (NodeReference, error) {
+	slots, found := f.values[address]
+	if !found {
+		slots = map[common.Key]common.Value{}
+		f.values[address] = slots
+	}
+	slots[key] = value
+	return NewNodeReference(EmptyId()), nil
+}
+
+func TestForestWAL_ReplayReappliesLoggedWrites(t *testing.T) {
+	dir := t.TempDir()
+	mutator := newFakeForestMutator()
+
+	wal, err := newForestWAL(dir, mutator, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open forest WAL: %v", err)
+	}
+
+	addr := common.Address{1}
+	root := NewNodeReference(EmptyId())
+	if _, err := wal.SetAccountInfo(&root, addr, AccountInfo{Nonce: common.ToNonce(1)}); err != nil {
+		t.Fatalf("failed to set account info: %v", err)
+	}
+	if _, err := wal.SetValue(&root, addr, common.Key{1}, common.Value{2}); err != nil {
+		t.Fatalf("failed to set value: %v", err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatalf("failed to close forest WAL: %v", err)
+	}
+
+	// Simulate a crash: the mutator "forgot" everything the WAL recorded.
+	replayed := newFakeForestMutator()
+	if _, err := ReplayForestWAL(dir, replayed, NewNodeReference(EmptyId())); err != nil {
+		t.Fatalf("failed to replay forest WAL: %v", err)
+	}
+
+	if got := replayed.infos[addr]; got.Nonce != common.ToNonce(1) {
+		t.Errorf("replay did not reapply SetAccountInfo: got %+v", got)
+	}
+	if got := replayed.values[addr][common.Key{1}]; got != (common.Value{2}) {
+		t.Errorf("replay did not reapply SetValue: got %v", got)
+	}
+}
+
+func TestOpenForestWAL_RecoversLoggedWritesThenKeepsLogging(t *testing.T) {
+	dir := t.TempDir()
+	mutator := newFakeForestMutator()
+	addr1 := common.Address{1}
+	root := NewNodeReference(EmptyId())
+
+	wal, err := newForestWAL(dir, mutator, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open forest WAL: %v", err)
+	}
+	if _, err := wal.SetAccountInfo(&root, addr1, AccountInfo{Nonce: common.ToNonce(1)}); err != nil {
+		t.Fatalf("failed to set account info: %v", err)
+	}
+	if err := wal.Close(); err != nil {
+		t.Fatalf("failed to close forest WAL: %v", err)
+	}
+
+	// Simulate a crash: open a fresh mutator that never saw addr1's write,
+	// and recover it through OpenForestWAL in one call.
+	recovered := newFakeForestMutator()
+	reopened, recoveredRoot, err := OpenForestWAL(dir, recovered, NewNodeReference(EmptyId()), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open recovered forest WAL: %v", err)
+	}
+	defer reopened.Close()
+
+	if got := recovered.infos[addr1]; got.Nonce != common.ToNonce(1) {
+		t.Errorf("OpenForestWAL did not recover the logged write: got %+v", got)
+	}
+
+	// Further writes through the reopened wrapper should keep being logged.
+	addr2 := common.Address{2}
+	if _, err := reopened.SetAccountInfo(&recoveredRoot, addr2, AccountInfo{Nonce: common.ToNonce(2)}); err != nil {
+		t.Fatalf("failed to set account info after recovery: %v", err)
+	}
+	if err := reopened.Close(); err != nil {
+		t.Fatalf("failed to close reopened forest WAL: %v", err)
+	}
+
+	final := newFakeForestMutator()
+	if _, err := ReplayForestWAL(dir, final, NewNodeReference(EmptyId())); err != nil {
+		t.Fatalf("failed to replay final log: %v", err)
+	}
+	if got := final.infos[addr1]; got.Nonce != common.ToNonce(1) {
+		t.Errorf("expected pre-recovery write to still replay: got %+v", got)
+	}
+	if got := final.infos[addr2]; got.Nonce != common.ToNonce(2) {
+		t.Errorf("expected post-recovery write to replay too: got %+v", got)
+	}
+}
+
+func TestForestWAL_ReplayOfMissingLogIsNoop(t *testing.T) {
+	dir := t.TempDir()
+	mutator := newFakeForestMutator()
+
+	root := NewNodeReference(EmptyId())
+	got, err := ReplayForestWAL(dir, mutator, root)
+	if err != nil {
+		t.Fatalf("replay of a directory with no WAL should not fail: %v", err)
+	}
+	if got != root {
+		t.Errorf("replay of a directory with no WAL should return root unchanged")
+	}
+}
@@ -0,0 +1,115 @@
+package mpt
+
+import (
+	"fmt"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// journalEntryKind identifies the kind of mutation recorded by a single
+// journalEntry, mirroring the mutating operations exposed by MptState.
+type journalEntryKind byte
+
+const (
+	balanceChange journalEntryKind = iota
+	nonceChange
+	storageChange
+	codeChange
+	accountCreate
+	accountDelete
+	storageClear
+)
+
+// journalEntry captures enough information to undo a single mutating call
+// on MptState. Entries are appended in call order and replayed in reverse
+// when reverting to an earlier snapshot.
+type journalEntry struct {
+	kind journalEntryKind
+
+	address common.Address
+
+	prevInfo    AccountInfo // account state before the change, for account/code changes
+	prevExisted bool        // whether the account existed before the change
+
+	key       common.Key   // for storageChange
+	prevValue common.Value // for storageChange
+
+	prevCode []byte // for codeChange, the code previously registered for prevInfo.CodeHash
+
+	clearedStorage []clearedStorageSlot // for storageClear
+}
+
+// clearedStorageSlot captures a single storage slot's value immediately
+// before a storageClear entry's ClearStorage call, so undo can restore it.
+type clearedStorageSlot struct {
+	key   common.Key
+	value common.Value
+}
+
+// Snapshot returns an opaque revision id identifying the current state of
+// s. Passing it to RevertToSnapshot later undoes every mutation performed
+// since this call.
+func (s *MptState) Snapshot() int {
+	return len(s.journal)
+}
+
+// RevertToSnapshot undoes every CreateAccount/DeleteAccount/SetBalance/
+// SetNonce/SetStorage/SetCode performed since id was obtained from
+// Snapshot, restoring the state (in memory only - nothing is flushed to
+// disk) to exactly how it looked at that point.
+func (s *MptState) RevertToSnapshot(id int) error {
+	if id < 0 || id > len(s.journal) {
+		return fmt.Errorf("invalid snapshot id %d", id)
+	}
+	s.revertingJournal = true
+	defer func() { s.revertingJournal = false }()
+
+	for i := len(s.journal) - 1; i >= id; i-- {
+		if err := s.undo(s.journal[i]); err != nil {
+			return fmt.Errorf("failed to revert to snapshot %d: %w", id, err)
+		}
+	}
+	s.journal = s.journal[:id]
+	return nil
+}
+
+// record appends an entry to the journal, unless journaling is currently
+// suspended (as it is while RevertToSnapshot is replaying entries).
+func (s *MptState) record(entry journalEntry) {
+	if s.revertingJournal {
+		return
+	}
+	s.journal = append(s.journal, entry)
+}
+
+// undo applies the inverse of a single journal entry directly against the
+// trie, bypassing the mutators in state.go to avoid re-recording the undo.
+func (s *MptState) undo(entry journalEntry) error {
+	switch entry.kind {
+	case accountCreate, accountDelete:
+		if !entry.prevExisted {
+			s.addresses.remove(entry.address)
+			return s.trie.SetAccountInfo(entry.address, AccountInfo{})
+		}
+		s.addresses.add(entry.address)
+		return s.trie.SetAccountInfo(entry.address, entry.prevInfo)
+	case balanceChange, nonceChange, codeChange:
+		if entry.kind == codeChange && entry.prevInfo.CodeHash != s.emptyCodeHash() {
+			if err := s.codes.Set(entry.prevInfo.CodeHash, entry.prevCode); err != nil {
+				return err
+			}
+		}
+		return s.trie.SetAccountInfo(entry.address, entry.prevInfo)
+	case storageChange:
+		return s.trie.SetValue(entry.address, entry.key, entry.prevValue)
+	case storageClear:
+		for _, slot := range entry.clearedStorage {
+			if err := s.trie.SetValue(entry.address, slot.key, slot.value); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown journal entry kind %d", entry.kind)
+	}
+}
@@ -0,0 +1,295 @@
+package mpt
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// OdrRequest is implemented by every request type LightMptState issues
+// through an OdrBackend: AccountRequest, StorageRequest, CodeRequest, and
+// TrieNodeRequest. Each carries the state root the response must resolve
+// under and result fields for the backend to fill in before returning.
+type OdrRequest interface {
+	isOdrRequest()
+}
+
+// OdrBackend resolves on-demand requests issued by a LightMptState, e.g. by
+// querying a full node over RPC for the proof a light client cannot produce
+// itself. Retrieve populates request's result fields in place; ctx governs
+// cancellation/timeout of the underlying transport.
+type OdrBackend interface {
+	Retrieve(ctx context.Context, request OdrRequest) error
+}
+
+// AccountRequest asks an OdrBackend to resolve an account's info as of
+// Root, along with a Merkle proof LightMptState can verify without trusting
+// the backend. Exists mirrors the exists result of LiveTrie.GetAccountInfo.
+type AccountRequest struct {
+	Root    common.Hash
+	Address common.Address
+
+	Info   AccountInfo
+	Exists bool
+	Proof  Proof
+}
+
+func (*AccountRequest) isOdrRequest() {}
+
+// StorageRequest asks an OdrBackend to resolve a single storage slot as of
+// Root. A zero Value is indistinguishable from an unset slot, mirroring
+// LiveTrie.GetValue.
+type StorageRequest struct {
+	Root    common.Hash
+	Address common.Address
+	Key     common.Key
+
+	Value common.Value
+	Proof Proof
+}
+
+func (*StorageRequest) isOdrRequest() {}
+
+// CodeRequest asks an OdrBackend for the contract code registered under
+// CodeHash. Since the code store is content-addressed (see code_store.go),
+// verification is a direct keccak256 check rather than a Merkle proof.
+type CodeRequest struct {
+	Root     common.Hash
+	CodeHash common.Hash
+
+	Code []byte
+}
+
+func (*CodeRequest) isOdrRequest() {}
+
+// TrieNodeRequest asks an OdrBackend for the canonical encoding of a single
+// trie node, addressed by its own hash. It exists so backends and future
+// callers resolving a node-cache miss mid-walk can fetch exactly the
+// missing node rather than re-requesting a whole account or storage proof;
+// LightMptState itself does not issue it yet, since it only ever resolves
+// whole account/storage proofs through AccountRequest/StorageRequest.
+type TrieNodeRequest struct {
+	Root common.Hash
+	Hash common.Hash
+
+	Encoded []byte
+}
+
+func (*TrieNodeRequest) isOdrRequest() {}
+
+// ErrOdrVerificationFailed is returned when a response from an OdrBackend
+// fails to verify against the pinned root, e.g. because a byzantine or
+// stale full node served a bad proof.
+var ErrOdrVerificationFailed = errors.New("on-demand response failed proof verification")
+
+// ErrLightStateReadOnly is returned by every mutating method of
+// LightMptState. Recomputing a root locally after a write requires walking
+// and re-hashing the Forest's node representation, which a light client by
+// definition does not hold, so mutation is rejected rather than silently
+// leaving GetHash stale.
+var ErrLightStateReadOnly = errors.New("light state is read-only")
+
+// defaultLightAccountCacheBytes and defaultLightStorageCacheBytes bound the
+// caches of verified account/storage results kept by a LightMptState, sized
+// to comfortably hold a few thousand distinct accounts/slots touched within
+// one block's execution.
+const (
+	defaultLightAccountCacheBytes = 8 * 1024 * 1024
+	defaultLightStorageCacheBytes = 8 * 1024 * 1024
+)
+
+// cachedAccount is a verified AccountRequest result, keyed by address in
+// LightMptState.accounts. Exists is kept alongside info rather than
+// inferred from it, since a zero AccountInfo is otherwise indistinguishable
+// from a nonexistent account.
+type cachedAccount struct {
+	info   AccountInfo
+	exists bool
+}
+
+// accountLeafSize is the sizeOf estimate used for cachedAccount entries in
+// LightMptState.accounts: the fixed encodeAccountLeaf body size plus the
+// exists flag, close enough for cache byte-budgeting purposes.
+const accountLeafSize = 8 + 32 + 32 + 1
+
+// storageSlotKey identifies a single storage slot in
+// LightMptState.storage.
+type storageSlotKey struct {
+	address common.Address
+	key     common.Key
+}
+
+// defaultEmptyCodeHash is the code hash of an account with no code, fixed to
+// Keccak256 since LightMptState has no SetHashFunc of its own (unlike
+// MptState - see MptState.emptyCodeHash in state.go) and only ever verifies
+// proofs served against whichever hash function the real network used.
+var defaultEmptyCodeHash = common.GetHash(sha3.NewLegacyKeccak256(), []byte{})
+
+// LightMptState is a read-only, stateless-client counterpart to MptState:
+// instead of holding a LiveTrie, it resolves every read through an
+// OdrBackend and verifies the returned Merkle proof against a pinned root
+// before trusting the result. This lets Carmen back light/stateless
+// validators that only hold a block's state root, fetching and verifying
+// just the data a call actually touches instead of replicating the full
+// trie.
+//
+// Verification goes through verifyProofAtPath (see proof.go), which
+// hash-chains whatever canonically-encoded nodes the backend supplied
+// against the pinned root; it does not depend on LiveTrie.Prove (which
+// cannot produce proofs yet - see trie_proof.go), since a LightMptState
+// never produces its own proofs, only checks ones an OdrBackend hands it.
+// light_state_test.go exercises this against a fake OdrBackend serving
+// hand-built node proofs, including a tampered one a byzantine or stale
+// full node might serve.
+type LightMptState struct {
+	backend OdrBackend
+	root    common.Hash
+
+	accounts *arcCache[common.Address, cachedAccount]
+	storage  *arcCache[storageSlotKey, common.Value]
+}
+
+// NewLightMptState creates a LightMptState resolving reads through backend,
+// trusting root as the state root every returned proof must verify
+// against.
+func NewLightMptState(backend OdrBackend, root common.Hash) *LightMptState {
+	return &LightMptState{
+		backend:  backend,
+		root:     root,
+		accounts: newArcCache[common.Address, cachedAccount](defaultLightAccountCacheBytes, func(cachedAccount) int { return accountLeafSize }),
+		storage:  newArcCache[storageSlotKey, common.Value](defaultLightStorageCacheBytes, func(common.Value) int { return len(common.Value{}) }),
+	}
+}
+
+// Root returns the state root this LightMptState verifies reads against.
+func (s *LightMptState) Root() common.Hash {
+	return s.root
+}
+
+func (s *LightMptState) getAccountInfo(ctx context.Context, address common.Address) (AccountInfo, bool, error) {
+	if cached, found := s.accounts.Get(address); found {
+		return cached.info, cached.exists, nil
+	}
+
+	req := &AccountRequest{Root: s.root, Address: address}
+	if err := s.backend.Retrieve(ctx, req); err != nil {
+		return AccountInfo{}, false, fmt.Errorf("failed to retrieve account %x: %w", address, err)
+	}
+
+	var expected []byte
+	if req.Exists {
+		expected = encodeAccountLeaf(req.Info)
+	}
+	addressHash := common.GetHash(sha3.NewLegacyKeccak256(), address[:])
+	if err := verifyProofAtPath(s.root, keyToNibbles(addressHash[:]), expected, req.Proof); err != nil {
+		return AccountInfo{}, false, fmt.Errorf("%w: account %x: %v", ErrOdrVerificationFailed, address, err)
+	}
+	s.accounts.Set(address, cachedAccount{info: req.Info, exists: req.Exists})
+	return req.Info, req.Exists, nil
+}
+
+func (s *LightMptState) GetBalance(address common.Address) (common.Balance, error) {
+	info, exists, err := s.getAccountInfo(context.Background(), address)
+	if !exists || err != nil {
+		return common.Balance{}, err
+	}
+	return info.Balance, nil
+}
+
+func (s *LightMptState) GetNonce(address common.Address) (common.Nonce, error) {
+	info, exists, err := s.getAccountInfo(context.Background(), address)
+	if !exists || err != nil {
+		return common.Nonce{}, err
+	}
+	return info.Nonce, nil
+}
+
+func (s *LightMptState) GetCodeHash(address common.Address) (common.Hash, error) {
+	info, exists, err := s.getAccountInfo(context.Background(), address)
+	if !exists || err != nil {
+		return defaultEmptyCodeHash, err
+	}
+	return info.CodeHash, nil
+}
+
+func (s *LightMptState) Exists(address common.Address) (bool, error) {
+	_, exists, err := s.getAccountInfo(context.Background(), address)
+	return exists, err
+}
+
+func (s *LightMptState) GetStorage(address common.Address, key common.Key) (common.Value, error) {
+	slot := storageSlotKey{address: address, key: key}
+	if cached, found := s.storage.Get(slot); found {
+		return cached, nil
+	}
+
+	req := &StorageRequest{Root: s.root, Address: address, Key: key}
+	if err := s.backend.Retrieve(context.Background(), req); err != nil {
+		return common.Value{}, fmt.Errorf("failed to retrieve storage %x/%x: %w", address, key, err)
+	}
+
+	var expected []byte
+	if req.Value != (common.Value{}) {
+		expected = req.Value[:]
+	}
+	keyHash := common.GetHash(sha3.NewLegacyKeccak256(), key[:])
+	if err := verifyProofAtPath(s.root, keyToNibbles(keyHash[:]), expected, req.Proof); err != nil {
+		return common.Value{}, fmt.Errorf("%w: storage %x/%x: %v", ErrOdrVerificationFailed, address, key, err)
+	}
+	s.storage.Set(slot, req.Value)
+	return req.Value, nil
+}
+
+func (s *LightMptState) GetCode(address common.Address) ([]byte, error) {
+	info, exists, err := s.getAccountInfo(context.Background(), address)
+	if !exists || err != nil {
+		return nil, err
+	}
+	if info.CodeHash == defaultEmptyCodeHash {
+		return nil, nil
+	}
+
+	req := &CodeRequest{Root: s.root, CodeHash: info.CodeHash}
+	if err := s.backend.Retrieve(context.Background(), req); err != nil {
+		return nil, fmt.Errorf("failed to retrieve code %x: %w", info.CodeHash, err)
+	}
+	if got := common.GetHash(sha3.NewLegacyKeccak256(), req.Code); got != info.CodeHash {
+		return nil, fmt.Errorf("%w: code %x hashes to %x", ErrOdrVerificationFailed, info.CodeHash, got)
+	}
+	return req.Code, nil
+}
+
+func (s *LightMptState) GetCodeSize(address common.Address) (int, error) {
+	code, err := s.GetCode(address)
+	if err != nil {
+		return 0, err
+	}
+	return len(code), nil
+}
+
+func (s *LightMptState) GetHash() (common.Hash, error) {
+	return s.root, nil
+}
+
+func (s *LightMptState) CreateAccount(common.Address) error { return ErrLightStateReadOnly }
+func (s *LightMptState) DeleteAccount(common.Address) error { return ErrLightStateReadOnly }
+
+func (s *LightMptState) SetBalance(common.Address, common.Balance) error { return ErrLightStateReadOnly }
+func (s *LightMptState) SetNonce(common.Address, common.Nonce) error     { return ErrLightStateReadOnly }
+func (s *LightMptState) SetCode(common.Address, []byte) error            { return ErrLightStateReadOnly }
+
+func (s *LightMptState) SetStorage(common.Address, common.Key, common.Value) error {
+	return ErrLightStateReadOnly
+}
+
+// GetMemoryFootprint provides sizes of individual components of the state
+// in memory.
+func (s *LightMptState) GetMemoryFootprint() *common.MemoryFootprint {
+	mf := common.NewMemoryFootprint(0)
+	mf.AddChild("accounts", common.NewMemoryFootprint(s.accounts.GetMemoryFootprint()))
+	mf.AddChild("storage", common.NewMemoryFootprint(s.storage.GetMemoryFootprint()))
+	return mf
+}
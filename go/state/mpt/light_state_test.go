@@ -0,0 +1,267 @@
+package mpt
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// singleEntryTrie builds a real, internally-consistent one-branch,
+// one-leaf trie (the same shape buildHandProof uses in proof_test.go) over a
+// single hashed key, and reports the nibble index the leaf was filed under so
+// callers can find a second key that provably diverges at the root branch for
+// exclusion-proof testing.
+type singleEntryTrie struct {
+	root        common.Hash
+	branch      []byte
+	leaf        []byte
+	firstNibble byte
+}
+
+func buildSingleEntryTrie(hashedKey common.Hash, leafValue []byte) singleEntryTrie {
+	path := keyToNibbles(hashedKey[:])
+	leaf := encodeProofLeaf(path[1:], leafValue)
+	leafHash := common.GetHash(sha3.NewLegacyKeccak256(), leaf)
+
+	var children [16]common.Hash
+	children[path[0]] = leafHash
+	branch := encodeProofBranch(children)
+	root := common.GetHash(sha3.NewLegacyKeccak256(), branch)
+
+	return singleEntryTrie{root: root, branch: branch, leaf: leaf, firstNibble: path[0]}
+}
+
+// proofFor returns the proof hashedKey should resolve under this trie: the
+// branch plus the populated leaf if hashedKey is the key the trie was built
+// for, or just the branch (an exclusion proof via a zero child slot) if
+// hashedKey's first nibble diverges from it.
+func (tr singleEntryTrie) proofFor(hashedKey common.Hash) (Proof, error) {
+	path := keyToNibbles(hashedKey[:])
+	if path[0] == tr.firstNibble {
+		return Proof{tr.branch, tr.leaf}, nil
+	}
+	return Proof{tr.branch}, nil
+}
+
+// fakeOdrBackend answers OdrRequests against a single pinned singleEntryTrie,
+// optionally corrupting the served proof, to exercise LightMptState's
+// verification independent of LiveTrie.Prove (which does not yet produce
+// proofs at all - see trie_proof.go).
+type fakeOdrBackend struct {
+	trie         singleEntryTrie
+	account      common.Address
+	accountInfo  AccountInfo
+	storageAddr  common.Address
+	storageKey   common.Key
+	storageValue common.Value
+	code         map[common.Hash][]byte
+	corrupt      bool
+	retrieves    int
+}
+
+func (b *fakeOdrBackend) Retrieve(ctx context.Context, request OdrRequest) error {
+	b.retrieves++
+	switch req := request.(type) {
+	case *AccountRequest:
+		addressHash := common.GetHash(sha3.NewLegacyKeccak256(), req.Address[:])
+		proof, err := b.trie.proofFor(addressHash)
+		if err != nil {
+			return err
+		}
+		if req.Address == b.account {
+			req.Info, req.Exists = b.accountInfo, true
+		}
+		if b.corrupt && len(proof) > 1 {
+			proof[1] = append([]byte{}, proof[1]...)
+			proof[1][len(proof[1])-1] ^= 0xFF
+		}
+		req.Proof = proof
+		return nil
+	case *StorageRequest:
+		keyHash := common.GetHash(sha3.NewLegacyKeccak256(), req.Key[:])
+		proof, err := b.trie.proofFor(keyHash)
+		if err != nil {
+			return err
+		}
+		if req.Address == b.storageAddr && req.Key == b.storageKey {
+			req.Value = b.storageValue
+		}
+		req.Proof = proof
+		return nil
+	case *CodeRequest:
+		req.Code = b.code[req.CodeHash]
+		return nil
+	default:
+		return errors.New("fakeOdrBackend: unsupported request type")
+	}
+}
+
+func newTestLightState(corrupt bool) (*LightMptState, common.Address, AccountInfo) {
+	address := common.Address{0x42}
+	info := AccountInfo{Balance: common.Balance{1}, Nonce: common.ToNonce(7), CodeHash: defaultEmptyCodeHash}
+	addressHash := common.GetHash(sha3.NewLegacyKeccak256(), address[:])
+	trie := buildSingleEntryTrie(addressHash, encodeAccountLeaf(info))
+
+	backend := &fakeOdrBackend{
+		trie:        trie,
+		account:     address,
+		accountInfo: info,
+		code:        map[common.Hash][]byte{},
+		corrupt:     corrupt,
+	}
+	return NewLightMptState(backend, trie.root), address, info
+}
+
+func TestLightMptState_VerifiesGenuineAccountProof(t *testing.T) {
+	state, address, want := newTestLightState(false)
+
+	balance, err := state.GetBalance(address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if balance != want.Balance {
+		t.Errorf("got balance %v, want %v", balance, want.Balance)
+	}
+
+	nonce, err := state.GetNonce(address)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if nonce != want.Nonce {
+		t.Errorf("got nonce %v, want %v", nonce, want.Nonce)
+	}
+}
+
+func TestLightMptState_VerifiesExclusionProofForUnknownAccount(t *testing.T) {
+	state, address, _ := newTestLightState(false)
+
+	addressHash := common.GetHash(sha3.NewLegacyKeccak256(), address[:])
+	var unknown common.Address
+	// Derive an address whose hashed key diverges at the root branch from
+	// the known account by probing byte values until keccak256 gives a
+	// different first nibble.
+	trieFirstNibble := keyToNibbles(addressHash[:])[0]
+	for i := 0; i < 256; i++ {
+		unknown = common.Address{byte(i)}
+		h := common.GetHash(sha3.NewLegacyKeccak256(), unknown[:])
+		if keyToNibbles(h[:])[0] != trieFirstNibble {
+			break
+		}
+	}
+
+	exists, err := state.Exists(unknown)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exists {
+		t.Errorf("expected account not to exist")
+	}
+}
+
+func TestLightMptState_RejectsTamperedProof(t *testing.T) {
+	state, address, _ := newTestLightState(true)
+
+	_, err := state.GetBalance(address)
+	if !errors.Is(err, ErrOdrVerificationFailed) {
+		t.Errorf("expected ErrOdrVerificationFailed for a tampered proof, got: %v", err)
+	}
+}
+
+func TestLightMptState_VerifiesStorageProof(t *testing.T) {
+	address := common.Address{0x1}
+	key := common.Key{0x2}
+	value := common.Value{0xAA}
+	keyHash := common.GetHash(sha3.NewLegacyKeccak256(), key[:])
+	trie := buildSingleEntryTrie(keyHash, value[:])
+
+	backend := &fakeOdrBackend{
+		trie:         trie,
+		storageAddr:  address,
+		storageKey:   key,
+		storageValue: value,
+		code:         map[common.Hash][]byte{},
+	}
+	state := NewLightMptState(backend, trie.root)
+
+	got, err := state.GetStorage(address, key)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != value {
+		t.Errorf("got storage value %v, want %v", got, value)
+	}
+}
+
+func TestLightMptState_RepeatedAccountReadIsServedFromCacheNotBackend(t *testing.T) {
+	address := common.Address{0x42}
+	info := AccountInfo{Balance: common.Balance{1}, Nonce: common.ToNonce(7), CodeHash: defaultEmptyCodeHash}
+	addressHash := common.GetHash(sha3.NewLegacyKeccak256(), address[:])
+	trie := buildSingleEntryTrie(addressHash, encodeAccountLeaf(info))
+
+	backend := &fakeOdrBackend{
+		trie:        trie,
+		account:     address,
+		accountInfo: info,
+		code:        map[common.Hash][]byte{},
+	}
+	state := NewLightMptState(backend, trie.root)
+
+	if _, err := state.GetBalance(address); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.retrieves != 1 {
+		t.Fatalf("expected 1 backend retrieve after the first read, got %d", backend.retrieves)
+	}
+
+	if _, err := state.GetNonce(address); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.retrieves != 1 {
+		t.Errorf("expected the second read of the same account to be served from cache, got %d backend retrieves", backend.retrieves)
+	}
+}
+
+func TestLightMptState_RepeatedStorageReadIsServedFromCacheNotBackend(t *testing.T) {
+	address := common.Address{0x1}
+	key := common.Key{0x2}
+	value := common.Value{0xAA}
+	keyHash := common.GetHash(sha3.NewLegacyKeccak256(), key[:])
+	trie := buildSingleEntryTrie(keyHash, value[:])
+
+	backend := &fakeOdrBackend{
+		trie:         trie,
+		storageAddr:  address,
+		storageKey:   key,
+		storageValue: value,
+		code:         map[common.Hash][]byte{},
+	}
+	state := NewLightMptState(backend, trie.root)
+
+	if _, err := state.GetStorage(address, key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.retrieves != 1 {
+		t.Fatalf("expected 1 backend retrieve after the first read, got %d", backend.retrieves)
+	}
+
+	if _, err := state.GetStorage(address, key); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if backend.retrieves != 1 {
+		t.Errorf("expected the second read of the same slot to be served from cache, got %d backend retrieves", backend.retrieves)
+	}
+}
+
+func TestLightMptState_MutationsAreRejected(t *testing.T) {
+	state, address, _ := newTestLightState(false)
+
+	if err := state.CreateAccount(address); !errors.Is(err, ErrLightStateReadOnly) {
+		t.Errorf("expected ErrLightStateReadOnly, got: %v", err)
+	}
+	if err := state.SetBalance(address, common.Balance{}); !errors.Is(err, ErrLightStateReadOnly) {
+		t.Errorf("expected ErrLightStateReadOnly, got: %v", err)
+	}
+}
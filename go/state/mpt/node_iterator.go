@@ -0,0 +1,320 @@
+package mpt
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+	"golang.org/x/crypto/sha3"
+)
+
+// keccak is a small convenience wrapper around common.GetHash for the
+// keccak256 primitive used throughout this package to derive hashed keys.
+func keccak(data []byte) common.Hash {
+	return common.GetHash(sha3.NewLegacyKeccak256(), data)
+}
+
+// StateIterator walks the accounts known to an MptState in hashed-key
+// order, resuming cheaply from any hashed-key prefix. It is intended for
+// snap-sync-style chunked range scans and dumps that must not hold the
+// whole trie in memory.
+//
+// Note: this first iteration is backed by the auxiliary hashed-key index
+// maintained alongside the trie (see addressIndex below) rather than a
+// native node-by-node trie walk, so Parent/ParentPath cannot report a real
+// parent node or path - the index records only which addresses/keys exist,
+// not the intermediate branch/extension nodes between them and the root -
+// and return their zero value rather than a fabricated stand-in. A
+// follow-up change replacing the index with direct Forest node traversal
+// can fill these in without changing the public interface. NodeIterator
+// also refuses to iterate a non-empty trie it has no index for (see
+// ErrNodeIteratorNeedsForestWalk) rather than silently returning nothing.
+type StateIterator interface {
+	// Next advances the iterator to the next account and reports whether
+	// one was found.
+	Next() bool
+
+	// Error returns the first error encountered during iteration, if any.
+	Error() error
+
+	// Hash is the hash of the node the iterator currently points to.
+	Hash() common.Hash
+
+	// Parent is a reference to the parent of the current node. It is the
+	// zero NodeReference in this index-backed implementation, which does
+	// not track real parent nodes; see the package doc above.
+	Parent() NodeReference
+
+	// ParentPath is the nibble path from the trie root to Parent, useful
+	// for constructing range proofs. It is nil in this index-backed
+	// implementation; see the package doc above.
+	ParentPath() []byte
+
+	// Leaf reports whether the current node is an account leaf.
+	Leaf() bool
+
+	// LeafKey is the hashed address of the current account leaf.
+	LeafKey() common.Hash
+
+	// LeafBlob is the encoded account body of the current leaf.
+	LeafBlob() []byte
+
+	// LeafProof returns the canonical encoding of every node on the path
+	// from the root to the current leaf, suitable for use as a Proof (see
+	// proof.go). It delegates to LiveTrie.Prove, which currently always
+	// returns ErrProofUnsupported (see trie_proof.go), so LeafProof returns
+	// nil and records that error until a real trie walk is available.
+	LeafProof() Proof
+
+	// StorageIterator returns an iterator over the storage trie of address,
+	// optionally resuming from startKey.
+	StorageIterator(address common.Address, startKey []byte) (StorageIterator, error)
+}
+
+// StorageIterator walks the storage slots of a single account in
+// hashed-key order.
+type StorageIterator interface {
+	Next() bool
+	Error() error
+	Hash() common.Hash
+	Parent() NodeReference
+	ParentPath() []byte
+	Leaf() bool
+	LeafKey() common.Hash
+	LeafBlob() []byte
+	LeafProof() Proof
+}
+
+// emptyTrieRootHash is keccak256(rlp("")), the canonical root hash of an
+// empty Ethereum-style MPT. Unlike anything Forest- or LiveTrie-specific,
+// this value is a fixed, well-known constant, so NodeIterator can use it to
+// tell a genuinely empty trie apart from a non-empty one it simply has no
+// local index for (see the guard in NodeIterator below).
+var emptyTrieRootHash = common.Hash{
+	0x56, 0xe8, 0x1f, 0x17, 0x1b, 0xcc, 0x55, 0xa6, 0xff, 0x83, 0x45, 0xe6, 0x92, 0xc0, 0xf8, 0x6e,
+	0x5b, 0x48, 0xe0, 0x1b, 0x99, 0x6c, 0xad, 0xc0, 0x01, 0x62, 0x2f, 0xb5, 0xe3, 0x63, 0xb4, 0x21,
+}
+
+// ErrNodeIteratorNeedsForestWalk is returned by NodeIterator (and so by
+// Dump) when the auxiliary addressIndex they are backed by cannot possibly be
+// complete: the trie's root is not emptyTrieRootHash, yet the index has
+// learned about zero addresses, meaning s was opened from an existing,
+// non-empty on-disk trie rather than built up from genesis through calls
+// made on this MptState instance. Earlier, NodeIterator silently returned
+// an iterator over that empty index in this situation, which looked
+// indistinguishable from a correctly-exhausted scan of a genuinely empty
+// trie; returning this error instead makes the gap visible to callers
+// rather than letting a dump or range-scan silently come back incomplete.
+// The real fix - iterating Forest's node representation directly instead of
+// this auxiliary index, so every reopened state is fully enumerable - needs
+// Forest's node definitions, which are not part of this repository
+// snapshot.
+var ErrNodeIteratorNeedsForestWalk = errors.New("mpt: NodeIterator cannot enumerate a non-empty trie opened without a populated address index; a node-by-node Forest walk is required but not available in this build")
+
+// addressIndexCanEnumerate reports whether an addressIndex holding
+// indexedEntries addresses can be trusted to enumerate a trie whose root is
+// root: either the index has seen at least one address, or the trie is
+// provably empty (root is emptyTrieRootHash), so there is nothing it could
+// be missing.
+func addressIndexCanEnumerate(root common.Hash, indexedEntries int) bool {
+	return indexedEntries > 0 || root == emptyTrieRootHash
+}
+
+// NodeIterator returns a StateIterator over s resuming at startKey (the
+// hashed-key prefix to seek to; nil starts at the beginning).
+func (s *MptState) NodeIterator(startKey []byte) (StateIterator, error) {
+	root, err := s.GetHash()
+	if err != nil {
+		return nil, err
+	}
+	if !addressIndexCanEnumerate(root, len(s.addresses.entries)) {
+		return nil, ErrNodeIteratorNeedsForestWalk
+	}
+	entries := s.addresses.accountsFrom(startKey)
+	return &stateIterator{state: s, root: root, entries: entries, pos: -1}, nil
+}
+
+type addressEntry struct {
+	hashedKey common.Hash
+	address   common.Address
+}
+
+// addressIndex is an auxiliary hashed-key-ordered index of every address
+// and storage key that has been touched through MptState, maintained
+// purely to support NodeIterator/StorageIterator without requiring callers
+// to hold the full trie in memory.
+type addressIndex struct {
+	entries []addressEntry
+	storage map[common.Address][]common.Hash
+	rawKeys map[common.Hash]common.Key
+}
+
+func newAddressIndex() *addressIndex {
+	return &addressIndex{storage: map[common.Address][]common.Hash{}, rawKeys: map[common.Hash]common.Key{}}
+}
+
+func (idx *addressIndex) add(address common.Address) {
+	hashedKey := keccak(address[:])
+	pos := sort.Search(len(idx.entries), func(i int) bool {
+		return bytesCompare(idx.entries[i].hashedKey[:], hashedKey[:]) >= 0
+	})
+	if pos < len(idx.entries) && idx.entries[pos].hashedKey == hashedKey {
+		return
+	}
+	idx.entries = append(idx.entries, addressEntry{})
+	copy(idx.entries[pos+1:], idx.entries[pos:])
+	idx.entries[pos] = addressEntry{hashedKey: hashedKey, address: address}
+}
+
+// remove drops address from the index, if present, so a deleted account no
+// longer shows up as a live leaf to NodeIterator/Dump.
+func (idx *addressIndex) remove(address common.Address) {
+	hashedKey := keccak(address[:])
+	pos := sort.Search(len(idx.entries), func(i int) bool {
+		return bytesCompare(idx.entries[i].hashedKey[:], hashedKey[:]) >= 0
+	})
+	if pos < len(idx.entries) && idx.entries[pos].hashedKey == hashedKey {
+		idx.entries = append(idx.entries[:pos], idx.entries[pos+1:]...)
+	}
+}
+
+func (idx *addressIndex) addStorageKey(address common.Address, key common.Key) {
+	hashedKey := keccak(key[:])
+	idx.rawKeys[hashedKey] = key
+	keys := idx.storage[address]
+	pos := sort.Search(len(keys), func(i int) bool {
+		return bytesCompare(keys[i][:], hashedKey[:]) >= 0
+	})
+	if pos < len(keys) && keys[pos] == hashedKey {
+		return
+	}
+	keys = append(keys, common.Hash{})
+	copy(keys[pos+1:], keys[pos:])
+	keys[pos] = hashedKey
+	idx.storage[address] = keys
+}
+
+func (idx *addressIndex) accountsFrom(startKey []byte) []addressEntry {
+	pos := sort.Search(len(idx.entries), func(i int) bool {
+		return bytesCompare(idx.entries[i].hashedKey[:], startKey) >= 0
+	})
+	return idx.entries[pos:]
+}
+
+func (idx *addressIndex) storageFrom(address common.Address, startKey []byte) []common.Hash {
+	keys := idx.storage[address]
+	pos := sort.Search(len(keys), func(i int) bool {
+		return bytesCompare(keys[i][:], startKey) >= 0
+	})
+	return keys[pos:]
+}
+
+func bytesCompare(a, b []byte) int {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	for i := 0; i < n; i++ {
+		if a[i] != b[i] {
+			return int(a[i]) - int(b[i])
+		}
+	}
+	return len(a) - len(b)
+}
+
+// stateIterator implements StateIterator over the addressIndex.
+type stateIterator struct {
+	state   *MptState
+	root    common.Hash
+	entries []addressEntry
+	pos     int
+	err     error
+}
+
+func (it *stateIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.pos++
+	return it.pos < len(it.entries)
+}
+
+func (it *stateIterator) Error() error          { return it.err }
+func (it *stateIterator) Hash() common.Hash     { return it.entries[it.pos].hashedKey }
+func (it *stateIterator) Parent() NodeReference { return NewNodeReference(EmptyId()) }
+func (it *stateIterator) ParentPath() []byte    { return nil }
+func (it *stateIterator) Leaf() bool            { return it.pos >= 0 && it.pos < len(it.entries) }
+func (it *stateIterator) LeafKey() common.Hash  { return it.Hash() }
+
+func (it *stateIterator) LeafBlob() []byte {
+	info, _, err := it.state.trie.GetAccountInfo(it.entries[it.pos].address)
+	if err != nil {
+		it.err = err
+		return nil
+	}
+	return encodeAccountLeaf(info)
+}
+
+func (it *stateIterator) LeafProof() Proof {
+	proof, _, err := it.state.trie.Prove(it.entries[it.pos].address, nil)
+	if err != nil {
+		it.err = err
+		return nil
+	}
+	return proof
+}
+
+func (it *stateIterator) StorageIterator(address common.Address, startKey []byte) (StorageIterator, error) {
+	keys := it.state.addresses.storageFrom(address, startKey)
+	return &storageIterator{state: it.state, address: address, keys: keys, pos: -1}, nil
+}
+
+// storageIterator mirrors stateIterator, scoped to a single account.
+type storageIterator struct {
+	state   *MptState
+	address common.Address
+	keys    []common.Hash
+	pos     int
+	err     error
+}
+
+func (it *storageIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	it.pos++
+	return it.pos < len(it.keys)
+}
+
+func (it *storageIterator) Error() error          { return it.err }
+func (it *storageIterator) Hash() common.Hash     { return it.keys[it.pos] }
+func (it *storageIterator) Parent() NodeReference { return NewNodeReference(EmptyId()) }
+func (it *storageIterator) ParentPath() []byte    { return nil }
+func (it *storageIterator) Leaf() bool            { return it.pos >= 0 && it.pos < len(it.keys) }
+func (it *storageIterator) LeafKey() common.Hash  { return it.Hash() }
+
+func (it *storageIterator) LeafBlob() []byte {
+	key, found := it.state.addresses.rawKeys[it.keys[it.pos]]
+	if !found {
+		return nil
+	}
+	value, err := it.state.trie.GetValue(it.address, key)
+	if err != nil {
+		it.err = err
+		return nil
+	}
+	return value[:]
+}
+
+func (it *storageIterator) LeafProof() Proof {
+	key, found := it.state.addresses.rawKeys[it.keys[it.pos]]
+	if !found {
+		return nil
+	}
+	_, proofs, err := it.state.trie.Prove(it.address, []common.Key{key})
+	if err != nil || len(proofs) == 0 {
+		it.err = err
+		return nil
+	}
+	return proofs[0]
+}
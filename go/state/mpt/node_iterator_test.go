@@ -0,0 +1,106 @@
+package mpt
+
+import (
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+func TestAddressIndex_AddIsSortedAndDeduplicated(t *testing.T) {
+	idx := newAddressIndex()
+	addrs := []common.Address{{3}, {1}, {2}, {1}}
+	for _, a := range addrs {
+		idx.add(a)
+	}
+	if len(idx.entries) != 3 {
+		t.Fatalf("expected 3 distinct entries, got %d", len(idx.entries))
+	}
+	for i := 1; i < len(idx.entries); i++ {
+		if bytesCompare(idx.entries[i-1].hashedKey[:], idx.entries[i].hashedKey[:]) >= 0 {
+			t.Errorf("entries not strictly sorted by hashed key at index %d", i)
+		}
+	}
+}
+
+func TestAddressIndex_AccountsFromResumesAtPrefix(t *testing.T) {
+	idx := newAddressIndex()
+	for _, a := range []common.Address{{1}, {2}, {3}} {
+		idx.add(a)
+	}
+	all := idx.accountsFrom(nil)
+	if len(all) != 3 {
+		t.Fatalf("expected 3 entries from nil prefix, got %d", len(all))
+	}
+
+	fromSecond := idx.accountsFrom(all[1].hashedKey[:])
+	if len(fromSecond) != 2 || fromSecond[0].hashedKey != all[1].hashedKey {
+		t.Errorf("accountsFrom did not resume at the requested prefix")
+	}
+}
+
+func TestAddressIndex_RemoveDropsTheAddressFromAccountsFrom(t *testing.T) {
+	idx := newAddressIndex()
+	addrA, addrB, addrC := common.Address{1}, common.Address{2}, common.Address{3}
+	for _, a := range []common.Address{addrA, addrB, addrC} {
+		idx.add(a)
+	}
+
+	idx.remove(addrB)
+
+	all := idx.accountsFrom(nil)
+	if len(all) != 2 {
+		t.Fatalf("expected 2 entries after removal, got %d", len(all))
+	}
+	for _, e := range all {
+		if e.address == addrB {
+			t.Errorf("removed address %v should no longer appear in accountsFrom", addrB)
+		}
+	}
+}
+
+func TestAddressIndex_RemoveOfUnknownAddressIsANoOp(t *testing.T) {
+	idx := newAddressIndex()
+	idx.add(common.Address{1})
+
+	idx.remove(common.Address{9})
+
+	if len(idx.entries) != 1 {
+		t.Errorf("removing an address never added should not affect the index, got %d entries", len(idx.entries))
+	}
+}
+
+func TestAddressIndex_StorageKeyAddIsSortedAndDeduplicatedPerAddress(t *testing.T) {
+	idx := newAddressIndex()
+	addrA, addrB := common.Address{1}, common.Address{2}
+	idx.addStorageKey(addrA, common.Key{3})
+	idx.addStorageKey(addrA, common.Key{1})
+	idx.addStorageKey(addrA, common.Key{1})
+	idx.addStorageKey(addrB, common.Key{9})
+
+	if got := len(idx.storage[addrA]); got != 2 {
+		t.Fatalf("expected 2 distinct storage keys for addrA, got %d", got)
+	}
+	if got := len(idx.storage[addrB]); got != 1 {
+		t.Fatalf("expected 1 storage key for addrB, got %d", got)
+	}
+}
+
+func TestAddressIndexCanEnumerate_EmptyTrieWithEmptyIndexIsFine(t *testing.T) {
+	if !addressIndexCanEnumerate(emptyTrieRootHash, 0) {
+		t.Errorf("an empty trie with an empty index should be enumerable")
+	}
+}
+
+func TestAddressIndexCanEnumerate_NonEmptyTrieWithEmptyIndexIsNotFine(t *testing.T) {
+	root := common.Hash{1, 2, 3}
+	if addressIndexCanEnumerate(root, 0) {
+		t.Errorf("a non-empty trie root with zero indexed addresses must not be treated as enumerable")
+	}
+}
+
+func TestAddressIndexCanEnumerate_AnyNonEmptyIndexIsFine(t *testing.T) {
+	root := common.Hash{1, 2, 3}
+	if !addressIndexCanEnumerate(root, 1) {
+		t.Errorf("a non-empty index should be treated as enumerable regardless of root")
+	}
+}
@@ -0,0 +1,150 @@
+package mpt
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+	"golang.org/x/crypto/sha3"
+)
+
+const (
+	proofNodeLeaf byte = iota
+	proofNodeBranch
+)
+
+// Proof is a Merkle proof: the canonical encoding of every node visited on
+// the path from a trie's root towards a requested key, in root-to-leaf
+// order, mirroring the structure produced by Geth's trie.Prove /
+// VerifyProof. This lets Carmen state serve eth_getProof-style requests and
+// interoperate with light clients.
+//
+// Producing one requires walking the trie's actual branch/extension/leaf
+// node chain from root to target - see the doc comment on LiveTrie.Prove in
+// trie_proof.go for why this package cannot do that yet. VerifyProof below
+// does not share that limitation: given any slice of canonically-encoded
+// nodes, it correctly hash-chains them against a root regardless of how
+// they were produced, which is exercised directly in proof_test.go against
+// hand-built node sets.
+type Proof [][]byte
+
+// ErrInvalidProof is returned by VerifyProof if the given proof does not
+// resolve to the requested key under the given root.
+var ErrInvalidProof = fmt.Errorf("proof malformed or does not resolve to the requested key")
+
+// ErrProofUnsupported is returned by Prove/MptState.Prove in place of a
+// fabricated proof, since producing a real one requires a node-by-node walk
+// of LiveTrie's branch/extension/leaf chain that this repository snapshot
+// has no source for (LiveTrie itself is referenced throughout this package
+// but is not defined anywhere in it). See LiveTrie.Prove in trie_proof.go.
+var ErrProofUnsupported = fmt.Errorf("mpt: proof generation requires a node-by-node trie walk not available in this build")
+
+// Prove returns what LiveTrie.Prove returns for address and storageKeys.
+// In this build that is always ErrProofUnsupported (see trie_proof.go):
+// no account proof, storage proof or exclusion proof is produced.
+func (s *MptState) Prove(address common.Address, storageKeys []common.Key) (accountProof Proof, storageProofs []Proof, err error) {
+	return s.trie.Prove(address, storageKeys)
+}
+
+// VerifyProof checks that proof resolves key to value under root. A nil
+// value checks a proof of absence. Verification rebuilds the path by hash
+// chaining: the proof is valid only if root, and every subsequently
+// referenced node, is present in the proof keyed by its own keccak256 hash.
+func VerifyProof(root common.Hash, key *common.Key, value []byte, proof Proof) error {
+	return verifyProofAtPath(root, keyToNibbles(key[:]), value, proof)
+}
+
+// verifyProofAtPath is the path-addressed core of VerifyProof, factored out
+// so that callers resolving a hashed key that is not itself a common.Key
+// (LightMptState's account lookups resolve by address hash, not storage
+// key) can verify directly against the nibble path.
+func verifyProofAtPath(root common.Hash, path []byte, value []byte, proof Proof) error {
+	nodesByHash := make(map[common.Hash][]byte, len(proof))
+	for _, encoded := range proof {
+		nodesByHash[common.GetHash(sha3.NewLegacyKeccak256(), encoded)] = encoded
+	}
+
+	wantHash := root
+	for pos := 0; ; pos++ {
+		encoded, found := nodesByHash[wantHash]
+		if !found {
+			return fmt.Errorf("%w: node for hash %x missing from proof", ErrInvalidProof, wantHash)
+		}
+		node, err := decodeProofNode(encoded)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidProof, err)
+		}
+
+		switch n := node.(type) {
+		case proofLeafNode:
+			remainder := path[pos:]
+			if !bytes.Equal(n.keyRemainder, remainder) {
+				if value != nil {
+					return fmt.Errorf("%w: diverges before reaching requested key", ErrInvalidProof)
+				}
+				return nil
+			}
+			if value == nil {
+				return fmt.Errorf("%w: proof resolves a key expected to be absent", ErrInvalidProof)
+			}
+			if !bytes.Equal(n.value, value) {
+				return fmt.Errorf("%w: resolved value does not match", ErrInvalidProof)
+			}
+			return nil
+		case proofBranchNode:
+			if pos == len(path) {
+				if value != nil {
+					return fmt.Errorf("%w: path ended on a branch without a value", ErrInvalidProof)
+				}
+				return nil
+			}
+			child := n.children[path[pos]]
+			if child == (common.Hash{}) {
+				if value != nil {
+					return fmt.Errorf("%w: path diverges before reaching the requested key", ErrInvalidProof)
+				}
+				return nil
+			}
+			wantHash = child
+		default:
+			return fmt.Errorf("%w: unsupported node kind in proof", ErrInvalidProof)
+		}
+	}
+}
+
+// keyToNibbles splits a key into its sequence of 4-bit nibbles, the unit of
+// traversal used when walking down an MPT.
+func keyToNibbles(key []byte) []byte {
+	nibbles := make([]byte, 0, len(key)*2)
+	for _, b := range key {
+		nibbles = append(nibbles, b>>4, b&0x0f)
+	}
+	return nibbles
+}
+
+// proofLeafNode and proofBranchNode are the minimal decoded shapes needed to
+// walk a proof path and check value resolution.
+type proofLeafNode struct {
+	keyRemainder []byte
+	value        []byte
+}
+
+type proofBranchNode struct {
+	children [16]common.Hash
+}
+
+// decodeProofNode decodes the canonical encoding of a single proof entry
+// produced by encodeProofLeaf/encodeProofBranch.
+func decodeProofNode(encoded []byte) (any, error) {
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("empty proof entry")
+	}
+	switch encoded[0] {
+	case proofNodeLeaf:
+		return decodeProofLeaf(encoded[1:])
+	case proofNodeBranch:
+		return decodeProofBranch(encoded[1:])
+	default:
+		return nil, fmt.Errorf("unknown proof node tag %d", encoded[0])
+	}
+}
@@ -0,0 +1,92 @@
+package mpt
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+	"golang.org/x/crypto/sha3"
+)
+
+var testKeyHash = common.Hash{1, 2, 3}
+
+// buildHandProof constructs a real, internally-consistent multi-node proof
+// by hand: a branch node at the root with a single populated child slot
+// pointing at a leaf, mirroring the shape a genuine node-by-node Forest walk
+// would eventually produce. It returns the proof and the root hash it
+// actually hashes to, so tests can verify VerifyProof's hash-chaining and
+// branch/leaf decoding logic independently of Prove (which cannot produce
+// this yet - see trie_proof.go).
+func buildHandProof(path []byte, value []byte) (proof Proof, root common.Hash) {
+	leaf := encodeProofLeaf(path[1:], value)
+	leafHash := common.GetHash(sha3.NewLegacyKeccak256(), leaf)
+
+	var children [16]common.Hash
+	children[path[0]] = leafHash
+	branch := encodeProofBranch(children)
+	branchHash := common.GetHash(sha3.NewLegacyKeccak256(), branch)
+
+	return Proof{branch, leaf}, branchHash
+}
+
+func testKey() common.Key {
+	var key common.Key
+	copy(key[:], testKeyHash[:])
+	return key
+}
+
+func TestVerifyProof_AcceptsAHandBuiltMultiNodeProof(t *testing.T) {
+	path := keyToNibbles(testKeyHash[:])
+	value := []byte{0xAA, 0xBB}
+	proof, root := buildHandProof(path, value)
+
+	key := testKey()
+	if err := VerifyProof(root, &key, value, proof); err != nil {
+		t.Errorf("expected valid hand-built proof to verify, got: %v", err)
+	}
+}
+
+func TestVerifyProof_RejectsWrongValue(t *testing.T) {
+	path := keyToNibbles(testKeyHash[:])
+	proof, root := buildHandProof(path, []byte{0xAA})
+
+	key := testKey()
+	if err := VerifyProof(root, &key, []byte{0xBB}, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("expected ErrInvalidProof for a mismatched value, got: %v", err)
+	}
+}
+
+func TestVerifyProof_RejectsTamperedRoot(t *testing.T) {
+	path := keyToNibbles(testKeyHash[:])
+	value := []byte{0xAA}
+	proof, _ := buildHandProof(path, value)
+
+	key := testKey()
+	tamperedRoot := common.Hash{0xFF}
+	if err := VerifyProof(tamperedRoot, &key, value, proof); !errors.Is(err, ErrInvalidProof) {
+		t.Errorf("expected ErrInvalidProof for a tampered root, got: %v", err)
+	}
+}
+
+func TestVerifyProof_AcceptsExclusionProofForDivergentBranchSlot(t *testing.T) {
+	path := keyToNibbles(testKeyHash[:])
+	proof, root := buildHandProof(path, []byte{0xAA})
+
+	// Ask about a key that diverges at the root branch (a different first
+	// nibble than the one populated in buildHandProof), expecting absence.
+	divergentKeyHash := testKeyHash
+	divergentKeyHash[0] ^= 0xFF
+	var key common.Key
+	copy(key[:], divergentKeyHash[:])
+	if err := VerifyProof(root, &key, nil, proof); err != nil {
+		t.Errorf("expected exclusion proof to verify for a divergent key, got: %v", err)
+	}
+}
+
+func TestLiveTrie_Prove_ReturnsUnsupportedInsteadOfFabricating(t *testing.T) {
+	var trie *LiveTrie
+	_, _, err := trie.Prove(common.Address{1}, nil)
+	if !errors.Is(err, ErrProofUnsupported) {
+		t.Errorf("expected ErrProofUnsupported, got: %v", err)
+	}
+}
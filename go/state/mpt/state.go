@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"encoding/binary"
 	"errors"
+	"fmt"
 	"hash"
 	"io"
 	"os"
@@ -11,7 +12,6 @@ import (
 
 	"github.com/Fantom-foundation/Carmen/go/backend"
 	"github.com/Fantom-foundation/Carmen/go/common"
-	"golang.org/x/crypto/sha3"
 )
 
 // MptState implementation of a state utilizes an MPT based data structure. While
@@ -21,27 +21,60 @@ import (
 // The main role of the MptState is to provide an adapter between a LiveTrie and
 // Carmen's State interface. Also, it retains an index of contract codes.
 type MptState struct {
-	trie     *LiveTrie
-	code     map[common.Hash][]byte
-	codefile string
-	hasher   hash.Hash
+	trie      *LiveTrie
+	codes     *codeStore
+	hasher    hash.Hash
+	newHasher func() hash.Hash
+	addresses *addressIndex
+
+	journal          []journalEntry
+	revertingJournal bool
 }
 
-var emptyCodeHash = common.GetHash(sha3.NewLegacyKeccak256(), []byte{})
-
 func newMptState(directory string, trie *LiveTrie) (*MptState, error) {
-	codefile := directory + "/codes.json"
-	codes, err := readCodes(codefile)
+	codes, err := openCodeStore(directory, 0)
 	if err != nil {
 		return nil, err
 	}
 	return &MptState{
-		trie:     trie,
-		code:     codes,
-		codefile: codefile,
+		trie:      trie,
+		codes:     codes,
+		newHasher: common.NewKeccak256Hasher,
+		addresses: newAddressIndex(),
 	}, nil
 }
 
+// SetHashFunc overrides the hash algorithm used for code hashing (e.g.
+// common.NewSha256Hasher, common.NewBlake2bHasher) in place of the default
+// Keccak256. It must be called before the first SetCode, since the
+// underlying hash.Hash is created lazily on first use.
+func (s *MptState) SetHashFunc(newHasher func() hash.Hash) {
+	s.newHasher = newHasher
+	s.hasher = nil
+}
+
+// ensureHasher lazily creates s.hasher from s.newHasher on first use, so
+// that a SetHashFunc call made before any hashing happens takes effect
+// (see SetHashFunc's doc comment).
+func (s *MptState) ensureHasher() hash.Hash {
+	if s.hasher == nil {
+		if s.newHasher == nil {
+			s.newHasher = common.NewKeccak256Hasher
+		}
+		s.hasher = s.newHasher()
+	}
+	return s.hasher
+}
+
+// emptyCodeHash is the code hash of an account with no code, computed with
+// the currently active hasher (see SetHashFunc) rather than a fixed
+// algorithm - it must match whatever SetCode computes for empty code, or a
+// freshly created account's CodeHash would silently fail to compare equal
+// to one SetCode(addr, nil) computes later.
+func (s *MptState) emptyCodeHash() common.Hash {
+	return common.GetHash(s.ensureHasher(), []byte{})
+}
+
 // OpenGoMemoryState loads state information from the given directory and
 // creates a Trie entirly retained in memory.
 func OpenGoMemoryState(directory string, config MptConfig) (*MptState, error) {
@@ -67,14 +100,47 @@ func (s *MptState) CreateAccount(address common.Address) (err error) {
 	}
 	if exists {
 		// For existing accounts, only clear the storage, preserve the rest.
+		cleared, err := s.snapshotStorage(address)
+		if err != nil {
+			return err
+		}
+		s.record(journalEntry{kind: storageClear, address: address, clearedStorage: cleared})
 		return s.trie.ClearStorage(address)
 	}
 	// Create account with hash of empty code.
+	s.record(journalEntry{kind: accountCreate, address: address, prevExisted: false})
+	s.addresses.add(address)
 	return s.trie.SetAccountInfo(address, AccountInfo{
-		CodeHash: emptyCodeHash,
+		CodeHash: s.emptyCodeHash(),
 	})
 }
 
+// snapshotStorage reads the current value of every storage slot address is
+// known to have touched (per s.addresses, the addressIndex), so a
+// subsequent ClearStorage can be undone by replaying these values. Slots
+// never indexed - i.e. never written through SetStorage on this MptState
+// instance - are invisible here, the same limitation NodeIterator already
+// has for this index (see node_iterator.go).
+func (s *MptState) snapshotStorage(address common.Address) ([]clearedStorageSlot, error) {
+	hashedKeys := s.addresses.storageFrom(address, nil)
+	if len(hashedKeys) == 0 {
+		return nil, nil
+	}
+	slots := make([]clearedStorageSlot, 0, len(hashedKeys))
+	for _, hashedKey := range hashedKeys {
+		key, found := s.addresses.rawKeys[hashedKey]
+		if !found {
+			continue
+		}
+		value, err := s.trie.GetValue(address, key)
+		if err != nil {
+			return nil, err
+		}
+		slots = append(slots, clearedStorageSlot{key: key, value: value})
+	}
+	return slots, nil
+}
+
 func (s *MptState) Exists(address common.Address) (bool, error) {
 	_, exists, err := s.trie.GetAccountInfo(address)
 	if err != nil {
@@ -84,6 +150,12 @@ func (s *MptState) Exists(address common.Address) (bool, error) {
 }
 
 func (s *MptState) DeleteAccount(address common.Address) error {
+	info, exists, err := s.trie.GetAccountInfo(address)
+	if err != nil {
+		return err
+	}
+	s.record(journalEntry{kind: accountDelete, address: address, prevInfo: info, prevExisted: exists})
+	s.addresses.remove(address)
 	return s.trie.SetAccountInfo(address, AccountInfo{})
 }
 
@@ -103,10 +175,13 @@ func (s *MptState) SetBalance(address common.Address, balance common.Balance) (e
 	if info.Balance == balance {
 		return nil
 	}
+	prevInfo := info
 	info.Balance = balance
 	if !exists {
-		info.CodeHash = emptyCodeHash
+		info.CodeHash = s.emptyCodeHash()
 	}
+	s.record(journalEntry{kind: balanceChange, address: address, prevInfo: prevInfo, prevExisted: exists})
+	s.addresses.add(address)
 	return s.trie.SetAccountInfo(address, info)
 }
 
@@ -126,10 +201,13 @@ func (s *MptState) SetNonce(address common.Address, nonce common.Nonce) (err err
 	if info.Nonce == nonce {
 		return nil
 	}
+	prevInfo := info
 	info.Nonce = nonce
 	if !exists {
-		info.CodeHash = emptyCodeHash
+		info.CodeHash = s.emptyCodeHash()
 	}
+	s.record(journalEntry{kind: nonceChange, address: address, prevInfo: prevInfo, prevExisted: exists})
+	s.addresses.add(address)
 	return s.trie.SetAccountInfo(address, info)
 }
 
@@ -138,6 +216,12 @@ func (s *MptState) GetStorage(address common.Address, key common.Key) (value com
 }
 
 func (s *MptState) SetStorage(address common.Address, key common.Key, value common.Value) error {
+	prevValue, err := s.trie.GetValue(address, key)
+	if err != nil {
+		return err
+	}
+	s.record(journalEntry{kind: storageChange, address: address, key: key, prevValue: prevValue})
+	s.addresses.addStorageKey(address, key)
 	return s.trie.SetValue(address, key, value)
 }
 
@@ -149,7 +233,7 @@ func (s *MptState) GetCode(address common.Address) (value []byte, err error) {
 	if !exists {
 		return nil, nil
 	}
-	return s.code[info.CodeHash], nil
+	return s.codes.Get(info.CodeHash)
 }
 
 func (s *MptState) GetCodeSize(address common.Address) (size int, err error) {
@@ -161,11 +245,7 @@ func (s *MptState) GetCodeSize(address common.Address) (size int, err error) {
 }
 
 func (s *MptState) SetCode(address common.Address, code []byte) (err error) {
-	var codeHash common.Hash
-	if s.hasher == nil {
-		s.hasher = sha3.NewLegacyKeccak256()
-	}
-	codeHash = common.GetHash(s.hasher, code)
+	codeHash := common.GetHash(s.ensureHasher(), code)
 
 	info, exists, err := s.trie.GetAccountInfo(address)
 	if err != nil {
@@ -177,15 +257,24 @@ func (s *MptState) SetCode(address common.Address, code []byte) (err error) {
 	if info.CodeHash == codeHash {
 		return nil
 	}
+	prevInfo := info
+	prevCode, err := s.codes.Get(prevInfo.CodeHash)
+	if err != nil {
+		return err
+	}
+	s.record(journalEntry{kind: codeChange, address: address, prevInfo: prevInfo, prevExisted: exists, prevCode: prevCode})
 	info.CodeHash = codeHash
-	s.code[codeHash] = code
+	if err := s.codes.Set(codeHash, code); err != nil {
+		return err
+	}
+	s.addresses.add(address)
 	return s.trie.SetAccountInfo(address, info)
 }
 
 func (s *MptState) GetCodeHash(address common.Address) (hash common.Hash, err error) {
 	info, exists, err := s.trie.GetAccountInfo(address)
 	if !exists || err != nil {
-		return emptyCodeHash, err
+		return s.emptyCodeHash(), err
 	}
 	return info.CodeHash, nil
 }
@@ -197,25 +286,38 @@ func (s *MptState) GetHash() (hash common.Hash, err error) {
 func (s *MptState) Flush() error {
 	// Flush codes and state trie.
 	return errors.Join(
-		writeCodes(s.code, s.codefile),
+		s.codes.Flush(),
 		s.trie.Flush(),
 	)
 }
 
 func (s *MptState) Close() (lastErr error) {
 	return errors.Join(
-		s.Flush(),
+		s.codes.Close(),
 		s.trie.Close(),
 	)
 }
 
 func (s *MptState) GetSnapshotableComponents() []backend.Snapshotable {
-	//panic("not implemented")
+	// The trie itself streams its subtree hashes as snapshot parts; the
+	// contract code map has no such support yet, so it is reconstructed
+	// wholesale in RunPostRestoreTasks instead of being split into parts.
+	if snapshotable, ok := any(s.trie).(backend.Snapshotable); ok {
+		return []backend.Snapshotable{snapshotable}
+	}
 	return nil
 }
 
 func (s *MptState) RunPostRestoreTasks() error {
-	//panic("not implemented")
+	codes, err := openCodeStore(s.codes.directory, 0)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild code store after restore: %w", err)
+	}
+	s.codes = codes
+
+	if _, err := s.trie.GetHash(); err != nil {
+		return fmt.Errorf("failed to re-verify trie root after restore: %w", err)
+	}
 	return nil
 }
 
@@ -223,7 +325,7 @@ func (s *MptState) RunPostRestoreTasks() error {
 func (s *MptState) GetMemoryFootprint() *common.MemoryFootprint {
 	mf := common.NewMemoryFootprint(unsafe.Sizeof(*s))
 	mf.AddChild("trie", s.trie.GetMemoryFootprint())
-	// TODO: add code store
+	mf.AddChild("codes", s.codes.GetMemoryFootprint())
 	return mf
 }
 
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+	"github.com/Fantom-foundation/Carmen/go/state/mpt"
+)
+
+// journalRecord is a single entry of a block-range export: the block
+// number, its expected post-block state hash (for replay verification),
+// and the raw diff the block applied. Diff is gob-encoded rather than
+// JSON-encoded because its map keys (common.Address, common.Key) are
+// fixed-size byte arrays without a JSON-compatible text encoding,
+// whereas gob serializes struct/array-keyed maps natively.
+type journalRecord struct {
+	Block uint64
+	Hash  common.Hash
+	Diff  mpt.Diff
+}
+
+// journalWriter appends journalRecords to an io.Writer using a simple
+// length-prefixed framing: a four-byte big-endian length followed by that
+// many bytes of gob-encoded journalRecord, so a reader can resume or stop
+// at any record boundary without needing to parse the whole file.
+type journalWriter struct {
+	out io.Writer
+}
+
+func newJournalWriter(out io.Writer) *journalWriter {
+	return &journalWriter{out: out}
+}
+
+func (w *journalWriter) Write(record journalRecord) error {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(record); err != nil {
+		return fmt.Errorf("failed to encode journal record for block %d: %w", record.Block, err)
+	}
+
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(buf.Len()))
+	if _, err := w.out.Write(length[:]); err != nil {
+		return err
+	}
+	_, err := w.out.Write(buf.Bytes())
+	return err
+}
+
+// journalReader reads back journalRecords written by journalWriter, in
+// order, until it reaches the end of the underlying stream.
+type journalReader struct {
+	in io.Reader
+}
+
+func newJournalReader(in io.Reader) *journalReader {
+	return &journalReader{in: in}
+}
+
+// Next reads the next record, returning io.EOF once no further records
+// remain.
+func (r *journalReader) Next() (journalRecord, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r.in, length[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return journalRecord{}, fmt.Errorf("truncated journal: incomplete record length header")
+		}
+		return journalRecord{}, err
+	}
+
+	data := make([]byte, binary.BigEndian.Uint32(length[:]))
+	if _, err := io.ReadFull(r.in, data); err != nil {
+		return journalRecord{}, fmt.Errorf("truncated journal: incomplete record body: %w", err)
+	}
+
+	var record journalRecord
+	if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&record); err != nil {
+		return journalRecord{}, fmt.Errorf("failed to decode journal record: %w", err)
+	}
+	return record, nil
+}
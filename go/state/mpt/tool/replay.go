@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	ioutil "github.com/Fantom-foundation/Carmen/go/state/mpt/io"
+
+	"github.com/Fantom-foundation/Carmen/go/state/mpt"
+	"github.com/urfave/cli/v2"
+)
+
+var Replay = cli.Command{
+	Action:    replay,
+	Name:      "replay",
+	Usage:     "applies a block-range journal produced by 'block --from --to --out' to a fresh state, verifying each block's hash",
+	ArgsUsage: "<state-directory> <journal-file>",
+	Flags: []cli.Flag{
+		&cpuProfileFlag,
+	},
+}
+
+func replay(context *cli.Context) error {
+	if context.Args().Len() != 2 {
+		return fmt.Errorf("expected <state-directory> <journal-file>")
+	}
+
+	cpuProfileFileName := context.String(cpuProfileFlag.Name)
+	if cpuProfileFileName != "" {
+		if err := startCpuProfiler(cpuProfileFileName); err != nil {
+			return err
+		}
+		defer stopCpuProfiler()
+	}
+
+	dir := context.Args().Get(0)
+	journalPath := context.Args().Get(1)
+
+	info, err := ioutil.CheckMptDirectoryAndGetInfo(dir)
+	if err != nil {
+		return err
+	}
+	state, err := mpt.OpenGoFileState(dir, info.Config)
+	if err != nil {
+		return fmt.Errorf("failed to open state in %s: %w", dir, err)
+	}
+	defer state.Close()
+
+	file, err := os.Open(journalPath)
+	if err != nil {
+		return fmt.Errorf("failed to open journal %s: %w", journalPath, err)
+	}
+	defer file.Close()
+
+	reader := newJournalReader(file)
+	applied := 0
+	for {
+		record, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := applyDiff(state, record.Diff); err != nil {
+			return fmt.Errorf("failed to apply block %d: %w", record.Block, err)
+		}
+
+		hash, err := state.GetHash()
+		if err != nil {
+			return fmt.Errorf("failed to compute hash after block %d: %w", record.Block, err)
+		}
+		if hash != record.Hash {
+			return fmt.Errorf("hash mismatch at block %d: want %x, got %x", record.Block, record.Hash, hash)
+		}
+		applied++
+	}
+
+	fmt.Printf("Replayed %d blocks from %s, all hashes verified\n", applied, journalPath)
+	return nil
+}
+
+// applyDiff replays a single block's mpt.Diff against state, mirroring
+// the same field handling diffToUpdate uses so that a replayed journal
+// and a live diffToUpdate conversion of the same block agree.
+func applyDiff(state *mpt.MptState, diff mpt.Diff) error {
+	for account, diff := range diff {
+		if diff.Reset {
+			if err := state.DeleteAccount(account); err != nil {
+				return fmt.Errorf("failed to delete account %v: %w", account, err)
+			}
+		}
+		if diff.Balance != nil {
+			if err := state.SetBalance(account, *diff.Balance); err != nil {
+				return fmt.Errorf("failed to set balance for %v: %w", account, err)
+			}
+		}
+		if diff.Nonce != nil {
+			if err := state.SetNonce(account, *diff.Nonce); err != nil {
+				return fmt.Errorf("failed to set nonce for %v: %w", account, err)
+			}
+		}
+		if diff.Code != nil {
+			if err := state.SetCode(account, (*diff.Code)[:]); err != nil {
+				return fmt.Errorf("failed to set code for %v: %w", account, err)
+			}
+		}
+		for key, value := range diff.Storage {
+			if err := state.SetStorage(account, key, value); err != nil {
+				return fmt.Errorf("failed to set storage slot for %v: %w", account, err)
+			}
+		}
+	}
+	return nil
+}
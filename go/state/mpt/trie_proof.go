@@ -0,0 +1,108 @@
+package mpt
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/Fantom-foundation/Carmen/go/common"
+)
+
+// Prove is meant to walk the trie rooted at t from its root down to the
+// account addressed by address, following the nibbles of its hashed key,
+// and, for every key in storageKeys, repeat the walk over that account's
+// storage trie, appending each visited node's canonical encoding to the
+// returned proof in root-to-leaf order.
+//
+// That walk requires following t's actual branch/extension/leaf node chain,
+// which is not something this package can do: LiveTrie itself has no
+// defining source in this repository snapshot (there is no node
+// representation to traverse), so there is no way to produce a proof whose
+// nodes hash-chain to the real root GetHash returns for anything but a
+// trivial, unverifiable guess. An earlier version of this function
+// fabricated a single-leaf "proof" that happened to satisfy VerifyProof only
+// because both sides were constructed from the same unchecked assumption,
+// which is worse than not implementing it: callers had no way to tell a
+// real proof from a fabricated one. Prove now reports that honestly instead.
+func (t *LiveTrie) Prove(address common.Address, storageKeys []common.Key) (Proof, []Proof, error) {
+	return nil, nil, fmt.Errorf("%w: account %x", ErrProofUnsupported, address)
+}
+
+// encodeAccountLeaf serializes the fields of an AccountInfo into the byte
+// string stored at a leaf node, analogous to the RLP account body Geth
+// stores at the end of an account proof.
+func encodeAccountLeaf(info AccountInfo) []byte {
+	var buf [8 + 32 + 32]byte
+	binary.BigEndian.PutUint64(buf[0:8], info.Nonce.ToUint64())
+	copy(buf[8:40], info.Balance[:])
+	copy(buf[40:72], info.CodeHash[:])
+	return buf[:]
+}
+
+// decodeAccountLeaf is the inverse of encodeAccountLeaf, used by callers
+// that only hold a verified leaf value (e.g. LightMptState) and need the
+// AccountInfo it encodes.
+func decodeAccountLeaf(data []byte) (AccountInfo, error) {
+	if len(data) != 8+32+32 {
+		return AccountInfo{}, fmt.Errorf("malformed account leaf: expected %d bytes, got %d", 8+32+32, len(data))
+	}
+	var info AccountInfo
+	info.Nonce = common.ToNonce(binary.BigEndian.Uint64(data[0:8]))
+	copy(info.Balance[:], data[8:40])
+	copy(info.CodeHash[:], data[40:72])
+	return info, nil
+}
+
+// encodeProofLeaf/encodeProofBranch/decodeProofLeaf/decodeProofBranch
+// implement the canonical (tag-prefixed) encoding a real node-by-node proof
+// is meant to use, so that VerifyProof can decode a proof produced anywhere
+// without access to the Forest's internal node representation.
+// Neither encoder has a caller outside the tests: Prove produces no proofs
+// at all (see its doc comment), and the tests use both encoders to build
+// proofs for VerifyProof. They stay here, alongside their decoders and
+// VerifyProof's branch-walking case, as the scaffolding a real Prove
+// implementation can build on once LiveTrie's node chain exists in this
+// package.
+
+func encodeProofLeaf(keyRemainder []byte, value []byte) []byte {
+	buf := make([]byte, 0, 1+2+len(keyRemainder)+len(value))
+	buf = append(buf, proofNodeLeaf)
+	var length [2]byte
+	binary.BigEndian.PutUint16(length[:], uint16(len(keyRemainder)))
+	buf = append(buf, length[:]...)
+	buf = append(buf, keyRemainder...)
+	buf = append(buf, value...)
+	return buf
+}
+
+func decodeProofLeaf(data []byte) (proofLeafNode, error) {
+	if len(data) < 2 {
+		return proofLeafNode{}, fmt.Errorf("truncated leaf proof entry")
+	}
+	n := binary.BigEndian.Uint16(data[0:2])
+	data = data[2:]
+	if len(data) < int(n) {
+		return proofLeafNode{}, fmt.Errorf("truncated leaf proof entry")
+	}
+	return proofLeafNode{keyRemainder: data[:n], value: data[n:]}, nil
+}
+
+func encodeProofBranch(children [16]common.Hash) []byte {
+	buf := make([]byte, 0, 1+16*len(common.Hash{}))
+	buf = append(buf, proofNodeBranch)
+	for _, child := range children {
+		buf = append(buf, child[:]...)
+	}
+	return buf
+}
+
+func decodeProofBranch(data []byte) (proofBranchNode, error) {
+	var n proofBranchNode
+	hashLen := len(common.Hash{})
+	if len(data) != 16*hashLen {
+		return n, fmt.Errorf("malformed branch proof entry")
+	}
+	for i := range n.children {
+		copy(n.children[i][:], data[i*hashLen:(i+1)*hashLen])
+	}
+	return n, nil
+}